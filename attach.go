@@ -0,0 +1,85 @@
+package main
+
+import (
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/int128/cronjob-runner/runner"
+	"github.com/spf13/pflag"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+)
+
+type attachOptions struct {
+	runner.WaitForJobOptions
+	Namespace string
+	JobName   string
+}
+
+func runAttach(clientset kubernetes.Interface, opts attachOptions) error {
+	ctx, stop := notifyContext()
+	defer stop()
+
+	return runner.AttachJob(ctx, clientset, opts.Namespace, opts.JobName, opts.WaitForJobOptions)
+}
+
+// attachMain runs the attach command, which waits for an existing Job.
+func attachMain(args []string) {
+	var opts attachOptions
+	var onCancel string
+	var tailLines int64
+	var logOpts logOptions
+	flags := pflag.NewFlagSet("attach", pflag.ExitOnError)
+	flags.StringVar(&opts.JobName, "job-name", "", "Name of Job")
+	flags.DurationVar(&opts.ContainerLogSince, "since", 0,
+		"Show the container logs newer than a relative duration such as 5m (default to all logs)")
+	flags.Int64Var(&tailLines, "tail", -1,
+		"Number of lines from the end of the container logs to show (default to all logs)")
+	flags.DurationVar(&opts.Timeout, "timeout", 0,
+		"Maximum duration to wait for the Job. On expiry, the Job is deleted (default to no timeout)")
+	flags.BoolVar(&opts.ShowNormalEvents, "show-normal-events", false,
+		"Show the Normal events of the Job and Pod(s) in addition to the Warning events")
+	flags.StringVar(&onCancel, "on-cancel", "keep",
+		"Must be one of keep, delete or suspend. Clean up the Job when this command is interrupted")
+	flags.DurationVar(&opts.ContainerLogMaxRetryDuration, "container-log-max-retry-duration", 5*time.Minute,
+		"Maximum duration to retry tailing a container log on errors")
+	logOpts.addFlags(flags)
+	kubernetesFlags := genericclioptions.NewConfigFlags(false)
+	kubernetesFlags.AddFlags(flags)
+	_ = flags.Parse(args)
+	if err := logOpts.setup(); err != nil {
+		// The status logger is not configured yet.
+		log.Printf("%s", err)
+		os.Exit(exitCodeUsage)
+	}
+	containerLogger, err := logOpts.containerLogger()
+	if err != nil {
+		slog.Error("Invalid flags", "error", err)
+		os.Exit(exitCodeUsage)
+	}
+	opts.ContainerLogger = containerLogger
+	opts.CI, err = logOpts.ciProvider()
+	if err != nil {
+		slog.Error("Invalid flags", "error", err)
+		os.Exit(exitCodeUsage)
+	}
+	opts.NeutralizeWorkflowCommands = logOpts.neutralize
+	if opts.JobName == "" {
+		slog.Error("You need to set --job-name")
+		os.Exit(exitCodeUsage)
+	}
+	if tailLines >= 0 {
+		opts.ContainerLogTailLines = &tailLines
+	}
+	opts.OnCancel = parseCancelPolicy(onCancel)
+
+	var clientset kubernetes.Interface
+	clientset, opts.Namespace = newClientset(kubernetesFlags)
+
+	if err := runAttach(clientset, opts); err != nil {
+		slog.Error("Failed to wait for the Job", "error", err)
+		os.Exit(exitCodeOf(err))
+	}
+}
@@ -0,0 +1,26 @@
+package annotation
+
+// Level represents the severity of an annotation.
+type Level string
+
+const (
+	LevelError   Level = "error"
+	LevelWarning Level = "warning"
+)
+
+// Annotation represents a problem to be highlighted in a CI system.
+type Annotation struct {
+	Level   Level
+	Title   string
+	Message string
+}
+
+// Annotator is an interface to emit annotations.
+type Annotator interface {
+	Annotate(a Annotation)
+}
+
+// Nop is an Annotator which does nothing.
+type Nop struct{}
+
+func (Nop) Annotate(Annotation) {}
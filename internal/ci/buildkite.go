@@ -0,0 +1,121 @@
+package ci
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/int128/cronjob-runner/internal/annotation"
+	"github.com/int128/cronjob-runner/internal/summary"
+)
+
+// buildkite writes the collapsible sections of the build log, and annotates the build.
+// https://buildkite.com/docs/pipelines/managing-log-output
+type buildkite struct{}
+
+func (buildkite) BeginSection(w io.Writer, _, title string) {
+	_, _ = fmt.Fprintf(w, "--- %s\n", strings.ReplaceAll(title, "\n", " "))
+}
+
+// EndSection does nothing, because a section continues until the next section.
+func (buildkite) EndSection(io.Writer, string) {}
+
+// NewAnnotator returns the annotator which runs buildkite-agent in the background.
+// It must be closed to wait for the pending annotations.
+func (buildkite) NewAnnotator(w io.Writer) annotation.Annotator {
+	a := &buildkiteAnnotator{
+		w:     w,
+		queue: make(chan buildkiteAnnotation, buildkiteAnnotationQueueSize),
+		done:  make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// AddMask does nothing, because the redaction of Buildkite is configured in the agent.
+func (buildkite) AddMask(io.Writer, string) {}
+
+// WriteSummary annotates the build with the summary.
+func (buildkite) WriteSummary(s summary.Summary) error {
+	var b strings.Builder
+	if err := s.WriteMarkdown(&b); err != nil {
+		return fmt.Errorf("write the summary: %w", err)
+	}
+	style := "success"
+	if s.Result != "Succeeded" {
+		style = "error"
+	}
+	context := fmt.Sprintf("cronjob-runner-%s-%s", s.JobNamespace, s.JobName)
+	return buildkiteAgentAnnotate(b.String(), "--style", style, "--context", context)
+}
+
+// buildkiteAnnotationQueueSize is the maximum number of the annotations waiting for buildkite-agent.
+const buildkiteAnnotationQueueSize = 100
+
+type buildkiteAnnotation struct {
+	body  string
+	style string
+}
+
+// buildkiteAnnotator annotates the build, and expands the current section of the build log.
+// Since buildkite-agent may take a while, it runs in the background
+// not to block the caller such as the event handlers of the informers.
+type buildkiteAnnotator struct {
+	w     io.Writer
+	queue chan buildkiteAnnotation
+	done  chan struct{}
+	once  sync.Once
+}
+
+func (a *buildkiteAnnotator) Annotate(an annotation.Annotation) {
+	// Expand the current section to show the problem.
+	_, _ = fmt.Fprintln(a.w, "^^^ +++")
+	style := "warning"
+	if an.Level == annotation.LevelError {
+		style = "error"
+	}
+	body := fmt.Sprintf("**%s**\n\n%s\n", an.Title, an.Message)
+	select {
+	case a.queue <- buildkiteAnnotation{body: body, style: style}:
+	default:
+		slog.Warn("Dropped the annotation because too many annotations are pending", "title", an.Title)
+	}
+}
+
+func (a *buildkiteAnnotator) run() {
+	defer close(a.done)
+	for an := range a.queue {
+		if err := buildkiteAgentAnnotate(an.body, "--style", an.style, "--context", "cronjob-runner-"+an.style, "--append"); err != nil {
+			slog.Warn("Failed to annotate the build", "error", err)
+		}
+	}
+}
+
+// Close waits for the pending annotations.
+// Annotate must not be called after Close.
+func (a *buildkiteAnnotator) Close() error {
+	a.once.Do(func() { close(a.queue) })
+	<-a.done
+	return nil
+}
+
+// buildkiteAgentAnnotate runs buildkite-agent annotate.
+// It is a variable for testing.
+var buildkiteAgentAnnotate = func(body string, args ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "buildkite-agent", append([]string{"annotate"}, args...)...)
+	cmd.Stdin = strings.NewReader(body)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("buildkite-agent annotate: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package ci
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/int128/cronjob-runner/internal/annotation"
+	"github.com/int128/cronjob-runner/internal/summary"
+)
+
+// gitHubActions writes the workflow commands.
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions
+type gitHubActions struct{}
+
+func (gitHubActions) BeginSection(w io.Writer, _, title string) {
+	_, _ = fmt.Fprintf(w, "::group::%s\n", escapeData(title))
+}
+
+func (gitHubActions) EndSection(w io.Writer, _ string) {
+	_, _ = fmt.Fprintln(w, "::endgroup::")
+}
+
+func (gitHubActions) NewAnnotator(w io.Writer) annotation.Annotator {
+	return &gitHubActionsAnnotator{w: w}
+}
+
+// AddMask registers the value as a secret.
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#masking-a-value-in-a-log
+func (gitHubActions) AddMask(w io.Writer, value string) {
+	_, _ = fmt.Fprintf(w, "::add-mask::%s\n", escapeData(value))
+}
+
+// WriteSummary appends the summary to the job summary.
+// It does nothing if $GITHUB_STEP_SUMMARY is not set.
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#adding-a-job-summary
+func (gitHubActions) WriteSummary(s summary.Summary) error {
+	name := os.Getenv("GITHUB_STEP_SUMMARY")
+	if name == "" {
+		return nil
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	if err := s.WriteMarkdown(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	return nil
+}
+
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#setting-an-error-message
+type gitHubActionsAnnotator struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (a *gitHubActionsAnnotator) Annotate(an annotation.Annotation) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, _ = fmt.Fprintf(a.w, "::%s title=%s::%s\n", an.Level, escapeProperty(an.Title), escapeData(an.Message))
+}
+
+// escapeData escapes the message of a workflow command.
+// https://github.com/actions/toolkit/blob/main/packages/core/src/command.ts
+func escapeData(s string) string {
+	return dataEscaper.Replace(s)
+}
+
+// escapeProperty escapes the property value of a workflow command.
+func escapeProperty(s string) string {
+	return propertyEscaper.Replace(s)
+}
+
+var (
+	dataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	propertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
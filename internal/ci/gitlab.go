@@ -0,0 +1,61 @@
+package ci
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/int128/cronjob-runner/internal/annotation"
+	"github.com/int128/cronjob-runner/internal/summary"
+)
+
+// gitLab writes the collapsible sections of the job log.
+// https://docs.gitlab.com/ee/ci/jobs/job_logs.html#custom-collapsible-sections
+type gitLab struct{}
+
+// invalidSectionNameChars matches the characters not allowed in a section name.
+var invalidSectionNameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+func gitLabSectionName(id string) string {
+	return invalidSectionNameChars.ReplaceAllString(id, "_")
+}
+
+func (gitLab) BeginSection(w io.Writer, id, title string) {
+	_, _ = fmt.Fprintf(w, "\x1b[0Ksection_start:%d:%s[collapsed=true]\r\x1b[0K%s\n",
+		time.Now().Unix(), gitLabSectionName(id), title)
+}
+
+func (gitLab) EndSection(w io.Writer, id string) {
+	_, _ = fmt.Fprintf(w, "\x1b[0Ksection_end:%d:%s\r\x1b[0K\n", time.Now().Unix(), gitLabSectionName(id))
+}
+
+func (gitLab) NewAnnotator(w io.Writer) annotation.Annotator {
+	return &gitLabAnnotator{w: w}
+}
+
+// AddMask does nothing, because GitLab CI masks only the variables defined in the project settings.
+func (gitLab) AddMask(io.Writer, string) {}
+
+// WriteSummary does nothing, because GitLab CI does not support a job summary.
+func (gitLab) WriteSummary(summary.Summary) error {
+	return nil
+}
+
+// gitLabAnnotator writes the annotations as colored lines,
+// because GitLab CI does not support annotations in the job log.
+type gitLabAnnotator struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (a *gitLabAnnotator) Annotate(an annotation.Annotation) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	color := "\x1b[33;1m"
+	if an.Level == annotation.LevelError {
+		color = "\x1b[31;1m"
+	}
+	_, _ = fmt.Fprintf(a.w, "%s%s: %s\x1b[0m\n", color, an.Title, an.Message)
+}
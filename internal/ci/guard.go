@@ -0,0 +1,56 @@
+package ci
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/int128/cronjob-runner/internal/logs"
+)
+
+// CommandGuard prevents the container logs from being interpreted as workflow commands.
+// If a line looks like a workflow command, it is surrounded by ::stop-commands:: and the resume token.
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#stopping-and-starting-workflow-commands
+type CommandGuard struct {
+	w     io.Writer
+	inner Logger
+	token string
+
+	mu sync.Mutex
+}
+
+// NewCommandGuard returns a CommandGuard with a random token.
+// The token must not be predictable, otherwise a container can resume the commands.
+// w should be the same as the output of inner.
+func NewCommandGuard(w io.Writer, inner Logger) *CommandGuard {
+	return &CommandGuard{w: w, inner: inner, token: rand.Text()}
+}
+
+func (g *CommandGuard) Handle(record logs.Record) {
+	if !looksLikeCommand(record.Message) {
+		g.inner.Handle(record)
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	_, _ = fmt.Fprintf(g.w, "::stop-commands::%s\n", g.token)
+	g.inner.Handle(record)
+	_, _ = fmt.Fprintf(g.w, "::%s::\n", g.token)
+}
+
+func (g *CommandGuard) HandleStreamEnd(end logs.StreamEnd) {
+	if h, ok := g.inner.(streamEndHandler); ok {
+		h.HandleStreamEnd(end)
+	}
+}
+
+// looksLikeCommand returns true if the line may be interpreted as a workflow command.
+// The runner trims the leading Unicode whitespaces before "::",
+// and accepts the legacy syntax "##[command]" anywhere in the line.
+func looksLikeCommand(line string) bool {
+	return strings.HasPrefix(strings.TrimLeftFunc(line, unicode.IsSpace), "::") ||
+		strings.Contains(line, "##[")
+}
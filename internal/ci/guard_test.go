@@ -0,0 +1,52 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/int128/cronjob-runner/internal/logs"
+)
+
+func TestCommandGuard(t *testing.T) {
+	var b strings.Builder
+	g := NewCommandGuard(&b, &lineLogger{b: &b})
+	for _, message := range []string{
+		"hello",
+		"::add-mask::secret",
+		"  ::set-output name=foo::bar",
+		"##[set-env name=FOO;]bar",
+		"\u00a0::add-mask::secret",
+		"\v::add-mask::secret",
+		"foo ##[set-env name=A]b",
+		"world ::notice::",
+	} {
+		g.Handle(logs.Record{Message: message})
+	}
+	want := "hello\n" +
+		"::stop-commands::" + g.token + "\n" +
+		"::add-mask::secret\n" +
+		"::" + g.token + "::\n" +
+		"::stop-commands::" + g.token + "\n" +
+		"  ::set-output name=foo::bar\n" +
+		"::" + g.token + "::\n" +
+		"::stop-commands::" + g.token + "\n" +
+		"##[set-env name=FOO;]bar\n" +
+		"::" + g.token + "::\n" +
+		"::stop-commands::" + g.token + "\n" +
+		"\u00a0::add-mask::secret\n" +
+		"::" + g.token + "::\n" +
+		"::stop-commands::" + g.token + "\n" +
+		"\v::add-mask::secret\n" +
+		"::" + g.token + "::\n" +
+		"::stop-commands::" + g.token + "\n" +
+		"foo ##[set-env name=A]b\n" +
+		"::" + g.token + "::\n" +
+		"world ::notice::\n"
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+	if g.token == "" {
+		t.Errorf("token must not be empty")
+	}
+}
@@ -0,0 +1,80 @@
+package ci
+
+import (
+	"io"
+	"os"
+
+	"github.com/int128/cronjob-runner/internal/annotation"
+	"github.com/int128/cronjob-runner/internal/summary"
+)
+
+// Provider is an interface of the output of a CI system.
+type Provider interface {
+	// BeginSection writes the start of a collapsible section.
+	// id is a unique identifier of the section, and title is shown to the user.
+	BeginSection(w io.Writer, id, title string)
+
+	// EndSection writes the end of the section.
+	EndSection(w io.Writer, id string)
+
+	// NewAnnotator returns an Annotator which writes to w.
+	// If the Annotator implements io.Closer, it must be closed to flush the pending annotations.
+	NewAnnotator(w io.Writer) annotation.Annotator
+
+	// AddMask writes a command to hide the value in the rest of the CI log.
+	// It does nothing if the CI system does not support it.
+	AddMask(w io.Writer, value string)
+
+	// WriteSummary writes the summary of the run to the CI system.
+	// It does nothing if the CI system does not support it.
+	WriteSummary(s summary.Summary) error
+}
+
+// Name represents the name of a CI system.
+type Name string
+
+const (
+	None          Name = "none"
+	GitHubActions Name = "github"
+	GitLab        Name = "gitlab"
+	Buildkite     Name = "buildkite"
+)
+
+// Detect returns the name of the CI system from the environment variables.
+func Detect() Name {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return GitHubActions
+	case os.Getenv("GITLAB_CI") == "true":
+		return GitLab
+	case os.Getenv("BUILDKITE") == "true":
+		return Buildkite
+	}
+	return None
+}
+
+// New returns the Provider of the CI system.
+// If the name is unknown, it returns the provider which writes nothing.
+func New(name Name) Provider {
+	switch name {
+	case GitHubActions:
+		return gitHubActions{}
+	case GitLab:
+		return gitLab{}
+	case Buildkite:
+		return buildkite{}
+	}
+	return none{}
+}
+
+type none struct{}
+
+func (none) BeginSection(io.Writer, string, string) {}
+
+func (none) EndSection(io.Writer, string) {}
+
+func (none) NewAnnotator(io.Writer) annotation.Annotator { return annotation.Nop{} }
+
+func (none) AddMask(io.Writer, string) {}
+
+func (none) WriteSummary(summary.Summary) error { return nil }
@@ -0,0 +1,123 @@
+package ci
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/int128/cronjob-runner/internal/annotation"
+)
+
+func TestDetect(t *testing.T) {
+	for _, tc := range []struct {
+		env  map[string]string
+		want Name
+	}{
+		{env: map[string]string{"GITHUB_ACTIONS": "true"}, want: GitHubActions},
+		{env: map[string]string{"GITLAB_CI": "true"}, want: GitLab},
+		{env: map[string]string{"BUILDKITE": "true"}, want: Buildkite},
+		{env: map[string]string{}, want: None},
+	} {
+		t.Run(string(tc.want), func(t *testing.T) {
+			for _, key := range []string{"GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE"} {
+				t.Setenv(key, tc.env[key])
+			}
+			if got := Detect(); got != tc.want {
+				t.Errorf("Detect wants %s but got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestProvider_Section(t *testing.T) {
+	for _, tc := range []struct {
+		name Name
+		want string
+	}{
+		{name: GitHubActions, want: "::group::pod/app\nhello\n::endgroup::\n"},
+		{
+			name: GitLab,
+			want: "\x1b[0Ksection_start:0:pod_app_1[collapsed=true]\r\x1b[0Kpod/app\nhello\n" +
+				"\x1b[0Ksection_end:0:pod_app_1\r\x1b[0K\n",
+		},
+		{name: Buildkite, want: "--- pod/app\nhello\n"},
+		{name: None, want: "hello\n"},
+	} {
+		t.Run(string(tc.name), func(t *testing.T) {
+			var b strings.Builder
+			p := New(tc.name)
+			p.BeginSection(&b, "pod/app_1", "pod/app")
+			b.WriteString("hello\n")
+			p.EndSection(&b, "pod/app_1")
+			// Replace the timestamp of GitLab.
+			got := regexp.MustCompile(`section_(start|end):\d+:`).ReplaceAllString(b.String(), "section_$1:0:")
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProvider_NewAnnotator(t *testing.T) {
+	a := annotation.Annotation{
+		Level:   annotation.LevelError,
+		Title:   "Container app: exit code 1",
+		Message: "100% failed\nsee logs",
+	}
+
+	t.Run("github", func(t *testing.T) {
+		var b strings.Builder
+		New(GitHubActions).NewAnnotator(&b).Annotate(a)
+		want := "::error title=Container app%3A exit code 1::100%25 failed%0Asee logs\n"
+		if diff := cmp.Diff(want, b.String()); diff != "" {
+			t.Errorf("output mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("buildkite", func(t *testing.T) {
+		var got []string
+		restore := buildkiteAgentAnnotate
+		defer func() { buildkiteAgentAnnotate = restore }()
+		buildkiteAgentAnnotate = func(body string, args ...string) error {
+			got = append([]string{body}, args...)
+			return nil
+		}
+		var b strings.Builder
+		annotator := New(Buildkite).NewAnnotator(&b)
+		annotator.Annotate(a)
+		if err := annotator.(io.Closer).Close(); err != nil {
+			t.Fatalf("Close: %s", err)
+		}
+		if diff := cmp.Diff("^^^ +++\n", b.String()); diff != "" {
+			t.Errorf("output mismatch (-want +got):\n%s", diff)
+		}
+		want := []string{
+			"**Container app: exit code 1**\n\n100% failed\nsee logs\n",
+			"--style", "error", "--context", "cronjob-runner-error", "--append",
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("args mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestProvider_AddMask(t *testing.T) {
+	for _, tc := range []struct {
+		name Name
+		want string
+	}{
+		{name: GitHubActions, want: "::add-mask::p@ss%0Aword\n"},
+		{name: GitLab, want: ""},
+		{name: None, want: ""},
+	} {
+		t.Run(string(tc.name), func(t *testing.T) {
+			var b strings.Builder
+			New(tc.name).AddMask(&b, "p@ss\nword")
+			if diff := cmp.Diff(tc.want, b.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package ci
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/int128/cronjob-runner/internal/logs"
+	"github.com/int128/cronjob-runner/internal/pods"
+)
+
+// Logger is the interface of the underlying container logger.
+type Logger interface {
+	Handle(record logs.Record)
+}
+
+type streamEndHandler interface {
+	HandleStreamEnd(end logs.StreamEnd)
+}
+
+// DefaultMaxBufferSize is the default size of the buffered messages of SectionLogger.
+const DefaultMaxBufferSize = 16 * 1024 * 1024
+
+// SectionLogger wraps the logs of each container in a collapsible section of the CI log.
+//
+// Since sections cannot be nested or interleaved, only one container is shown at a time.
+// The logs of the other containers are buffered until the active container is finished.
+// A main container is preferred to be active, because a sidecar container lives until the pod ends.
+// If the buffer exceeds maxBufferSize, the active section is closed to flush the buffered logs,
+// and it is reopened as continued.
+type SectionLogger struct {
+	w             io.Writer
+	provider      Provider
+	inner         Logger
+	maxBufferSize int
+
+	mu            sync.Mutex
+	streams       map[streamKey]*stream
+	active        *stream
+	pending       []*stream
+	bufferedBytes int
+}
+
+type streamKey struct {
+	namespace     string
+	podName       string
+	containerName string
+}
+
+type stream struct {
+	key           streamKey
+	containerType pods.ContainerType
+	records       []logs.Record
+	end           *logs.StreamEnd
+	// opened is true if the section of the stream is open.
+	opened bool
+	// continued is true if the section has been closed before the stream is finished.
+	continued bool
+	// sections is the number of sections opened so far.
+	sections int
+}
+
+// NewSectionLogger returns a SectionLogger which writes the sections to w.
+// w should be the same as the output of inner.
+func NewSectionLogger(w io.Writer, provider Provider, inner Logger, maxBufferSize int) *SectionLogger {
+	return &SectionLogger{
+		w:             w,
+		provider:      provider,
+		inner:         inner,
+		maxBufferSize: maxBufferSize,
+		streams:       make(map[streamKey]*stream),
+	}
+}
+
+func (l *SectionLogger) Handle(record logs.Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := streamKey{namespace: record.Namespace, podName: record.PodName, containerName: record.ContainerName}
+	s, ok := l.streams[key]
+	if !ok {
+		s = &stream{key: key, containerType: record.ContainerType}
+		l.streams[key] = s
+		l.pending = append(l.pending, s)
+	}
+	if l.active != nil && l.active != s &&
+		s.containerType == pods.ContainerTypeMain && l.active.containerType != pods.ContainerTypeMain {
+		// A sidecar container lives until the pod ends, so the main container takes over the section.
+		l.closeSection(l.active)
+		l.pending = append(l.pending, l.active)
+		l.active = nil
+	}
+	if l.active == nil {
+		l.activateNext()
+	}
+	if s == l.active {
+		l.openSection(s)
+		l.inner.Handle(record)
+		return
+	}
+	s.records = append(s.records, record)
+	l.bufferedBytes += len(record.Message)
+	if l.bufferedBytes > l.maxBufferSize {
+		l.flushPending()
+	}
+}
+
+func (l *SectionLogger) HandleStreamEnd(end logs.StreamEnd) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := streamKey{namespace: end.Namespace, podName: end.PodName, containerName: end.ContainerName}
+	s, ok := l.streams[key]
+	if !ok {
+		// No record was received.
+		l.forwardStreamEnd(end)
+		return
+	}
+	s.end = &end
+	if s != l.active {
+		return
+	}
+	l.finish(s)
+	l.active = nil
+	l.activateNext()
+}
+
+// activateNext shows the pending streams in order.
+// The streams already finished are shown entirely,
+// and the first running main container becomes active.
+// If no main container is running, the first running stream becomes active.
+func (l *SectionLogger) activateNext() {
+	var running []*stream
+	for _, s := range l.pending {
+		if s.end != nil {
+			l.writeBuffered(s)
+			l.finish(s)
+			continue
+		}
+		running = append(running, s)
+	}
+	l.pending = running
+	if len(running) == 0 {
+		return
+	}
+	i := slices.IndexFunc(running, func(s *stream) bool { return s.containerType == pods.ContainerTypeMain })
+	if i < 0 {
+		i = 0
+	}
+	s := running[i]
+	l.pending = slices.Delete(running, i, i+1)
+	l.writeBuffered(s)
+	l.active = s
+}
+
+// flushPending closes the active section and shows the buffered logs of the pending streams.
+func (l *SectionLogger) flushPending() {
+	l.closeSection(l.active)
+	var running []*stream
+	for _, s := range l.pending {
+		l.writeBuffered(s)
+		if s.end != nil {
+			l.finish(s)
+			continue
+		}
+		l.closeSection(s)
+		running = append(running, s)
+	}
+	l.pending = running
+}
+
+// openSection begins the section of the stream if not opened yet.
+func (l *SectionLogger) openSection(s *stream) {
+	if s.opened {
+		return
+	}
+	title := fmt.Sprintf("%s/%s", s.key.podName, s.key.containerName)
+	if s.continued {
+		title += " (continued)"
+	}
+	s.sections++
+	l.provider.BeginSection(l.w, s.sectionID(), title)
+	s.opened = true
+}
+
+// closeSection ends the section of the stream if opened.
+// The section is reopened as continued on the next record.
+func (l *SectionLogger) closeSection(s *stream) {
+	if s == nil || !s.opened {
+		return
+	}
+	l.provider.EndSection(l.w, s.sectionID())
+	s.opened = false
+	s.continued = true
+}
+
+func (s *stream) sectionID() string {
+	return fmt.Sprintf("%s_%s_%d", s.key.podName, s.key.containerName, s.sections)
+}
+
+func (l *SectionLogger) writeBuffered(s *stream) {
+	if len(s.records) == 0 {
+		return
+	}
+	l.openSection(s)
+	for _, record := range s.records {
+		l.inner.Handle(record)
+		l.bufferedBytes -= len(record.Message)
+	}
+	s.records = nil
+}
+
+// finish closes the section of the finished stream.
+func (l *SectionLogger) finish(s *stream) {
+	l.closeSection(s)
+	delete(l.streams, s.key)
+	if s.end != nil {
+		l.forwardStreamEnd(*s.end)
+	}
+}
+
+func (l *SectionLogger) forwardStreamEnd(end logs.StreamEnd) {
+	if h, ok := l.inner.(streamEndHandler); ok {
+		h.HandleStreamEnd(end)
+	}
+}
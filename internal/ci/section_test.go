@@ -0,0 +1,143 @@
+package ci
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/int128/cronjob-runner/internal/logs"
+	"github.com/int128/cronjob-runner/internal/pods"
+)
+
+// lineLogger writes the message to the builder, like a plain container logger.
+type lineLogger struct {
+	b    *strings.Builder
+	ends []string
+}
+
+func (l *lineLogger) Handle(record logs.Record) {
+	_, _ = fmt.Fprintln(l.b, record.Message)
+}
+
+func (l *lineLogger) HandleStreamEnd(end logs.StreamEnd) {
+	l.ends = append(l.ends, end.ContainerName)
+}
+
+func TestSectionLogger(t *testing.T) {
+	record := func(containerName, message string) logs.Record {
+		return logs.Record{PodName: "pod", ContainerName: containerName, Message: message}
+	}
+	end := func(containerName string) logs.StreamEnd {
+		return logs.StreamEnd{PodName: "pod", ContainerName: containerName}
+	}
+
+	t.Run("interleaved containers", func(t *testing.T) {
+		var b strings.Builder
+		inner := &lineLogger{b: &b}
+		l := NewSectionLogger(&b, gitHubActions{}, inner, DefaultMaxBufferSize)
+		l.Handle(record("a", "a1"))
+		l.Handle(record("b", "b1"))
+		l.Handle(record("c", "c1"))
+		l.Handle(record("a", "a2"))
+		l.HandleStreamEnd(end("b"))
+		l.Handle(record("c", "c2"))
+		l.HandleStreamEnd(end("a"))
+		l.Handle(record("c", "c3"))
+		l.HandleStreamEnd(end("c"))
+
+		want := `::group::pod/a
+a1
+a2
+::endgroup::
+::group::pod/b
+b1
+::endgroup::
+::group::pod/c
+c1
+c2
+c3
+::endgroup::
+`
+		if diff := cmp.Diff(want, b.String()); diff != "" {
+			t.Errorf("output mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]string{"a", "b", "c"}, inner.ends); diff != "" {
+			t.Errorf("stream ends mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("main container is preferred to sidecar container", func(t *testing.T) {
+		var b strings.Builder
+		inner := &lineLogger{b: &b}
+		l := NewSectionLogger(&b, gitHubActions{}, inner, DefaultMaxBufferSize)
+		sidecar := func(message string) logs.Record {
+			r := record("proxy", message)
+			r.ContainerType = pods.ContainerTypeSidecar
+			return r
+		}
+		main := func(message string) logs.Record {
+			r := record("app", message)
+			r.ContainerType = pods.ContainerTypeMain
+			return r
+		}
+		l.Handle(sidecar("p1"))
+		l.Handle(main("m1"))
+		l.Handle(sidecar("p2"))
+		l.Handle(main("m2"))
+		l.HandleStreamEnd(end("app"))
+		l.Handle(sidecar("p3"))
+		l.HandleStreamEnd(end("proxy"))
+
+		want := `::group::pod/proxy
+p1
+::endgroup::
+::group::pod/app
+m1
+m2
+::endgroup::
+::group::pod/proxy (continued)
+p2
+p3
+::endgroup::
+`
+		if diff := cmp.Diff(want, b.String()); diff != "" {
+			t.Errorf("output mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]string{"app", "proxy"}, inner.ends); diff != "" {
+			t.Errorf("stream ends mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("buffer exceeds the limit", func(t *testing.T) {
+		var b strings.Builder
+		inner := &lineLogger{b: &b}
+		l := NewSectionLogger(&b, gitHubActions{}, inner, 4)
+		l.Handle(record("a", "a1"))
+		l.Handle(record("b", "b1"))
+		l.Handle(record("b", "b2"))
+		l.Handle(record("b", "b3"))
+		l.Handle(record("a", "a2"))
+		l.HandleStreamEnd(end("a"))
+		l.HandleStreamEnd(end("b"))
+
+		want := `::group::pod/a
+a1
+::endgroup::
+::group::pod/b
+b1
+b2
+b3
+::endgroup::
+::group::pod/a (continued)
+a2
+::endgroup::
+`
+		if diff := cmp.Diff(want, b.String()); diff != "" {
+			t.Errorf("output mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]string{"a", "b"}, inner.ends); diff != "" {
+			t.Errorf("stream ends mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,262 @@
+package events
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+type Informer interface {
+	// Shutdown implements informers.SharedInformerFactory#Shutdown
+	Shutdown()
+
+	// WaitForCacheSync implements informers.SharedInformerFactory#WaitForCacheSync
+	WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool
+
+	// ImagePulls returns the image pulls observed by the informer.
+	ImagePulls() []ImagePull
+}
+
+type informer struct {
+	// informerFactories is a list of the factories of the Job events and Pod events.
+	informerFactories []informers.SharedInformerFactory
+	handler           *eventHandler
+}
+
+func (i *informer) Shutdown() {
+	for _, informerFactory := range i.informerFactories {
+		informerFactory.Shutdown()
+	}
+}
+
+func (i *informer) WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool {
+	synced := make(map[reflect.Type]bool)
+	for _, informerFactory := range i.informerFactories {
+		for typ, ok := range informerFactory.WaitForCacheSync(stopCh) {
+			if prev, exists := synced[typ]; exists {
+				ok = ok && prev
+			}
+			synced[typ] = ok
+		}
+	}
+	return synced
+}
+
+func (i *informer) ImagePulls() []ImagePull {
+	i.handler.mu.Lock()
+	defer i.handler.mu.Unlock()
+	return append([]ImagePull(nil), i.handler.imagePulls...)
+}
+
+// ImagePull represents an image pulled for a container.
+type ImagePull struct {
+	PodName       string
+	ContainerName string
+	Duration      time.Duration
+}
+
+// StartInformer starts an informer to receive the events of the Job and its Pods.
+// isJobPod determines whether the pod belongs to the Job.
+// It should know the existing pods before the informer is started,
+// otherwise the events in the initial list may be dropped.
+// You must finally close stopCh to stop the informer.
+// When the watch is dropped by an error, it is sent to watchErrorHandler if set.
+// It shows the Warning events, and also the Normal events if showNormal is true.
+func StartInformer(
+	clientset kubernetes.Interface,
+	namespace, jobName string,
+	isJobPod func(podName string) bool,
+	showNormal bool,
+	stopCh <-chan struct{},
+	watchErrorHandler cache.WatchErrorHandler,
+) (Informer, error) {
+	handler := &eventHandler{
+		jobName:    jobName,
+		isJobPod:   isJobPod,
+		showNormal: showNormal,
+		seen:       make(map[eventKey]struct{}),
+	}
+	// Events cannot be filtered by the owner, so the Pod events are filtered in the handler.
+	// It lists and watches the events of all Pods in the namespace,
+	// which may be costly in a namespace with many Pods.
+	fieldSelectors := []string{
+		fmt.Sprintf("regarding.kind=Job,regarding.name=%s", jobName),
+		"regarding.kind=Pod",
+	}
+	var informerFactories []informers.SharedInformerFactory
+	for _, fieldSelector := range fieldSelectors {
+		informerFactory := informers.NewSharedInformerFactoryWithOptions(clientset, time.Hour*24,
+			informers.WithNamespace(namespace),
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.FieldSelector = fieldSelector
+			}),
+		)
+		sharedInformer := informerFactory.Events().V1().Events().Informer()
+		if watchErrorHandler != nil {
+			if err := sharedInformer.SetWatchErrorHandler(watchErrorHandler); err != nil {
+				return nil, fmt.Errorf("set the watch error handler: %w", err)
+			}
+		}
+		if _, err := sharedInformer.AddEventHandler(handler); err != nil {
+			return nil, fmt.Errorf("add an event handler to the informer: %w", err)
+		}
+		informerFactories = append(informerFactories, informerFactory)
+	}
+	for _, informerFactory := range informerFactories {
+		informerFactory.Start(stopCh)
+	}
+	slog.Info("Watching Event",
+		slog.Group("job", slog.String("namespace", namespace), slog.String("name", jobName)))
+	return &informer{informerFactories: informerFactories, handler: handler}, nil
+}
+
+// eventKey is a key to deduplicate the repeated events.
+type eventKey struct {
+	kind   string
+	name   string
+	reason string
+	note   string
+}
+
+type eventHandler struct {
+	jobName    string
+	isJobPod   func(podName string) bool
+	showNormal bool
+
+	mu         sync.Mutex
+	seen       map[eventKey]struct{}
+	imagePulls []ImagePull
+}
+
+func (h *eventHandler) OnAdd(obj interface{}, _ bool) {
+	h.handle(obj.(*eventsv1.Event))
+}
+
+func (h *eventHandler) OnUpdate(_, newObj interface{}) {
+	h.handle(newObj.(*eventsv1.Event))
+}
+
+func (h *eventHandler) OnDelete(interface{}) {
+	// Events are deleted by TTL, nothing to do.
+}
+
+func (h *eventHandler) handle(event *eventsv1.Event) {
+	if !h.isRelevant(event) {
+		return
+	}
+	if imagePull, ok := parseImagePull(event); ok {
+		h.recordImagePull(imagePull)
+	}
+	if event.Type != corev1.EventTypeWarning && !h.showNormal {
+		return
+	}
+	regardingAttr := slog.Group(strings.ToLower(event.Regarding.Kind),
+		slog.String("namespace", event.Regarding.Namespace),
+		slog.String("name", event.Regarding.Name))
+	if !h.markAsSeen(event) {
+		slog.Debug("Event is repeated", regardingAttr,
+			slog.String("reason", event.Reason),
+			slog.Int("count", countOf(event)))
+		return
+	}
+	msg := fmt.Sprintf("%s event", event.Regarding.Kind)
+	attrs := []any{regardingAttr,
+		slog.String("type", event.Type),
+		slog.String("reason", event.Reason),
+		slog.String("message", event.Note),
+		slog.Int("count", countOf(event)),
+	}
+	if event.Type == corev1.EventTypeWarning {
+		slog.Warn(msg, attrs...)
+		return
+	}
+	slog.Info(msg, attrs...)
+}
+
+func (h *eventHandler) isRelevant(event *eventsv1.Event) bool {
+	switch event.Regarding.Kind {
+	case "Job":
+		return event.Regarding.Name == h.jobName
+	case "Pod":
+		return h.isJobPod(event.Regarding.Name)
+	}
+	return false
+}
+
+// markAsSeen returns true if the event is seen for the first time.
+func (h *eventHandler) markAsSeen(event *eventsv1.Event) bool {
+	key := eventKey{
+		kind:   event.Regarding.Kind,
+		name:   event.Regarding.Name,
+		reason: event.Reason,
+		note:   event.Note,
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.seen[key]; ok {
+		return false
+	}
+	h.seen[key] = struct{}{}
+	return true
+}
+
+func (h *eventHandler) recordImagePull(imagePull ImagePull) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, p := range h.imagePulls {
+		if p == imagePull {
+			return
+		}
+	}
+	h.imagePulls = append(h.imagePulls, imagePull)
+}
+
+// pulledDurationPattern matches the note of Pulled event, such as
+// `Successfully pulled image "busybox" in 1.377s (1.377s including waiting)`.
+var pulledDurationPattern = regexp.MustCompile(`^Successfully pulled image ".+?" in (\S+?)(?: |$)`)
+
+// containerFieldPathPattern matches the field path of a container, such as spec.containers{app}.
+var containerFieldPathPattern = regexp.MustCompile(`^spec\.(?:initContainers|containers)\{(.+)\}$`)
+
+// parseImagePull returns the image pull if the event is Pulled of a container.
+func parseImagePull(event *eventsv1.Event) (ImagePull, bool) {
+	if event.Regarding.Kind != "Pod" || event.Reason != "Pulled" {
+		return ImagePull{}, false
+	}
+	fieldPath := containerFieldPathPattern.FindStringSubmatch(event.Regarding.FieldPath)
+	if fieldPath == nil {
+		return ImagePull{}, false
+	}
+	// If the image is already present on the node, the note has no duration.
+	m := pulledDurationPattern.FindStringSubmatch(event.Note)
+	if m == nil {
+		return ImagePull{}, false
+	}
+	d, err := time.ParseDuration(m[1])
+	if err != nil {
+		return ImagePull{}, false
+	}
+	return ImagePull{PodName: event.Regarding.Name, ContainerName: fieldPath[1], Duration: d}, true
+}
+
+// countOf returns the number of occurrences of the event.
+func countOf(event *eventsv1.Event) int {
+	if event.Series != nil {
+		return int(event.Series.Count)
+	}
+	if event.DeprecatedCount > 0 {
+		return int(event.DeprecatedCount)
+	}
+	return 1
+}
@@ -0,0 +1,104 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEventHandler_isRelevant(t *testing.T) {
+	h := &eventHandler{
+		jobName:  "example-job",
+		isJobPod: func(podName string) bool { return podName == "example-job-abcde" },
+	}
+	for _, tc := range []struct {
+		regarding corev1.ObjectReference
+		want      bool
+	}{
+		{regarding: corev1.ObjectReference{Kind: "Job", Name: "example-job"}, want: true},
+		{regarding: corev1.ObjectReference{Kind: "Job", Name: "other-job"}, want: false},
+		{regarding: corev1.ObjectReference{Kind: "Pod", Name: "example-job-abcde"}, want: true},
+		{regarding: corev1.ObjectReference{Kind: "Pod", Name: "other-job-abcde"}, want: false},
+		{regarding: corev1.ObjectReference{Kind: "Node", Name: "example-job"}, want: false},
+	} {
+		t.Run(tc.regarding.Kind+"/"+tc.regarding.Name, func(t *testing.T) {
+			got := h.isRelevant(&eventsv1.Event{Regarding: tc.regarding})
+			if got != tc.want {
+				t.Errorf("isRelevant wants %v but got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestEventHandler_markAsSeen(t *testing.T) {
+	h := &eventHandler{seen: make(map[eventKey]struct{})}
+	event := &eventsv1.Event{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-job-abcde.1"},
+		Regarding:  corev1.ObjectReference{Kind: "Pod", Name: "example-job-abcde"},
+		Reason:     "BackOff",
+		Note:       "Back-off restarting failed container",
+	}
+	if !h.markAsSeen(event) {
+		t.Errorf("markAsSeen wants true for the first event")
+	}
+	repeated := event.DeepCopy()
+	repeated.Series = &eventsv1.EventSeries{Count: 2}
+	if h.markAsSeen(repeated) {
+		t.Errorf("markAsSeen wants false for the repeated event")
+	}
+	other := event.DeepCopy()
+	other.Reason = "Killing"
+	if !h.markAsSeen(other) {
+		t.Errorf("markAsSeen wants true for the event of another reason")
+	}
+}
+
+func TestParseImagePull(t *testing.T) {
+	for name, tc := range map[string]struct {
+		event  eventsv1.Event
+		want   ImagePull
+		wantOK bool
+	}{
+		"pulled": {
+			event: eventsv1.Event{
+				Regarding: corev1.ObjectReference{Kind: "Pod", Name: "example-pod", FieldPath: "spec.containers{app}"},
+				Reason:    "Pulled",
+				Note:      `Successfully pulled image "busybox" in 1.377s (1.377s including waiting). Image size: 2160406 bytes.`,
+			},
+			want:   ImagePull{PodName: "example-pod", ContainerName: "app", Duration: 1377 * time.Millisecond},
+			wantOK: true,
+		},
+		"pulled by old kubelet": {
+			event: eventsv1.Event{
+				Regarding: corev1.ObjectReference{Kind: "Pod", Name: "example-pod", FieldPath: "spec.initContainers{init}"},
+				Reason:    "Pulled",
+				Note:      `Successfully pulled image "busybox" in 2m3.5s`,
+			},
+			want:   ImagePull{PodName: "example-pod", ContainerName: "init", Duration: 2*time.Minute + 3500*time.Millisecond},
+			wantOK: true,
+		},
+		"already present": {
+			event: eventsv1.Event{
+				Regarding: corev1.ObjectReference{Kind: "Pod", Name: "example-pod", FieldPath: "spec.containers{app}"},
+				Reason:    "Pulled",
+				Note:      `Container image "busybox" already present on machine`,
+			},
+		},
+		"other reason": {
+			event: eventsv1.Event{
+				Regarding: corev1.ObjectReference{Kind: "Pod", Name: "example-pod", FieldPath: "spec.containers{app}"},
+				Reason:    "Started",
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, ok := parseImagePull(&tc.event)
+			if ok != tc.wantOK || got != tc.want {
+				t.Errorf("parseImagePull wants (%+v, %v) but got (%+v, %v)", tc.want, tc.wantOK, got, ok)
+			}
+		})
+	}
+}
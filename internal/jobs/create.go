@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"slices"
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -12,9 +13,62 @@ import (
 	"k8s.io/utils/ptr"
 )
 
+// Options represents a set of options for NewFromCronJob.
+type Options struct {
+	// Env is a map of environment variables injected to the containers.
+	Env map[string]string
+
+	// SecretEnv is a map of environment variables injected to the containers via SecretRef.
+	SecretEnv map[string]string
+
+	// SecretRef is a reference to the Secret of SecretEnv.
+	SecretRef *corev1.LocalObjectReference
+
+	// EnvContainerNames is a list of container names to inject Env and SecretEnv.
+	// Init containers are also available.
+	// Default to all containers except init containers.
+	EnvContainerNames []string
+
+	// Command overrides the command of the container.
+	// If Command is set, the args of the container are replaced with Args.
+	Command []string
+
+	// Args overrides the args of the container.
+	Args []string
+
+	// ContainerName is the name of container to override the command and args.
+	// Default to the first container.
+	ContainerName string
+
+	// Images is a map of container name to image.
+	// Init containers are also available.
+	Images map[string]string
+
+	// ActiveDeadlineSeconds is set to the Job, if the job template has no stricter deadline.
+	ActiveDeadlineSeconds *int64
+}
+
 // NewFromCronJob creates a job from the CronJob template.
-// If env is given, it injects the environment variables to all containers.
-func NewFromCronJob(cronJob *batchv1.CronJob, env, secretEnv map[string]string, secretRef *corev1.LocalObjectReference) *batchv1.Job {
+// If env is given, it injects the environment variables to the containers.
+// If a container of the options is not found, it returns an error.
+func NewFromCronJob(cronJob *batchv1.CronJob, opts Options) (*batchv1.Job, error) {
+	jobSpec, err := appendEnv(cronJob.Spec.JobTemplate.Spec, opts.Env, opts.EnvContainerNames)
+	if err != nil {
+		return nil, fmt.Errorf("append the env: %w", err)
+	}
+	jobSpec, err = appendSecretEnv(jobSpec, opts.SecretEnv, opts.SecretRef, opts.EnvContainerNames)
+	if err != nil {
+		return nil, fmt.Errorf("append the secret env: %w", err)
+	}
+	jobSpec, err = overrideCommand(jobSpec, opts.ContainerName, opts.Command, opts.Args)
+	if err != nil {
+		return nil, fmt.Errorf("override the command: %w", err)
+	}
+	jobSpec, err = overrideImages(jobSpec, opts.Images)
+	if err != nil {
+		return nil, fmt.Errorf("override the image: %w", err)
+	}
+	jobSpec = limitActiveDeadlineSeconds(jobSpec, opts.ActiveDeadlineSeconds)
 	return &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace:    cronJob.Namespace,
@@ -30,60 +84,142 @@ func NewFromCronJob(cronJob *batchv1.CronJob, env, secretEnv map[string]string,
 			Labels:      cronJob.Spec.JobTemplate.Labels,
 			Annotations: cronJob.Spec.JobTemplate.Annotations,
 		},
-		Spec: appendSecretEnv(appendEnv(cronJob.Spec.JobTemplate.Spec, env), secretEnv, secretRef),
-	}
+		Spec: jobSpec,
+	}, nil
 }
 
-func appendEnv(jobSpec batchv1.JobSpec, env map[string]string) batchv1.JobSpec {
+func appendEnv(jobSpec batchv1.JobSpec, env map[string]string, containerNames []string) (batchv1.JobSpec, error) {
 	if len(env) == 0 {
-		return jobSpec
+		return jobSpec, nil
 	}
 
-	var newContainers []corev1.Container
-	for _, container := range jobSpec.Template.Spec.Containers {
-		var newEnv []corev1.EnvVar
-		newEnv = append(newEnv, container.Env...)
-		for name, value := range env {
-			newEnv = append(newEnv, corev1.EnvVar{Name: name, Value: value})
-		}
-		newContainer := container.DeepCopy()
-		newContainer.Env = newEnv
-		newContainers = append(newContainers, *newContainer)
+	var newEnv []corev1.EnvVar
+	for name, value := range env {
+		newEnv = append(newEnv, corev1.EnvVar{Name: name, Value: value})
 	}
-	newSpec := jobSpec.DeepCopy()
-	newSpec.Template.Spec.Containers = newContainers
-	return *newSpec
+	return appendContainerEnv(jobSpec, newEnv, containerNames)
 }
 
-func appendSecretEnv(jobSpec batchv1.JobSpec, secretEnv map[string]string, secretRef *corev1.LocalObjectReference) batchv1.JobSpec {
+func appendSecretEnv(jobSpec batchv1.JobSpec, secretEnv map[string]string, secretRef *corev1.LocalObjectReference, containerNames []string) (batchv1.JobSpec, error) {
 	if secretRef == nil {
-		return jobSpec
+		return jobSpec, nil
 	}
 	if len(secretEnv) == 0 {
-		return jobSpec
+		return jobSpec, nil
 	}
 
-	var newContainers []corev1.Container
-	for _, container := range jobSpec.Template.Spec.Containers {
-		var newEnv []corev1.EnvVar
-		newEnv = append(newEnv, container.Env...)
-		for key := range secretEnv {
-			newEnv = append(newEnv, corev1.EnvVar{
-				Name: key,
-				ValueFrom: &corev1.EnvVarSource{
-					SecretKeyRef: &corev1.SecretKeySelector{
-						LocalObjectReference: *secretRef,
-						Key:                  key,
-					},
+	var newEnv []corev1.EnvVar
+	for key := range secretEnv {
+		newEnv = append(newEnv, corev1.EnvVar{
+			Name: key,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: *secretRef,
+					Key:                  key,
 				},
-			})
+			},
+		})
+	}
+	return appendContainerEnv(jobSpec, newEnv, containerNames)
+}
+
+// appendContainerEnv appends the environment variables to the containers.
+// If containerNames is empty, it appends to all containers except init containers.
+// Otherwise, it appends to the containers or init containers of the names.
+func appendContainerEnv(jobSpec batchv1.JobSpec, env []corev1.EnvVar, containerNames []string) (batchv1.JobSpec, error) {
+	for _, containerName := range containerNames {
+		if findContainer(jobSpec.Template.Spec, containerName) == nil {
+			return jobSpec, fmt.Errorf("container %s is not found in the job template", containerName)
+		}
+	}
+
+	newSpec := jobSpec.DeepCopy()
+	for i := range newSpec.Template.Spec.Containers {
+		container := &newSpec.Template.Spec.Containers[i]
+		if len(containerNames) == 0 || slices.Contains(containerNames, container.Name) {
+			container.Env = append(container.Env, env...)
+		}
+	}
+	for i := range newSpec.Template.Spec.InitContainers {
+		container := &newSpec.Template.Spec.InitContainers[i]
+		if slices.Contains(containerNames, container.Name) {
+			container.Env = append(container.Env, env...)
 		}
-		newContainer := container.DeepCopy()
-		newContainer.Env = newEnv
-		newContainers = append(newContainers, *newContainer)
+	}
+	return *newSpec, nil
+}
+
+// findContainer returns the container or init container of the name.
+// If not found, it returns nil.
+func findContainer(podSpec corev1.PodSpec, containerName string) *corev1.Container {
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == containerName {
+			return &podSpec.Containers[i]
+		}
+	}
+	for i := range podSpec.InitContainers {
+		if podSpec.InitContainers[i].Name == containerName {
+			return &podSpec.InitContainers[i]
+		}
+	}
+	return nil
+}
+
+func overrideCommand(jobSpec batchv1.JobSpec, containerName string, command, args []string) (batchv1.JobSpec, error) {
+	if len(command) == 0 && len(args) == 0 {
+		return jobSpec, nil
+	}
+
+	newSpec := jobSpec.DeepCopy()
+	containers := newSpec.Template.Spec.Containers
+	if len(containers) == 0 {
+		return jobSpec, fmt.Errorf("no container in the job template")
+	}
+	index := 0
+	if containerName != "" {
+		index = slices.IndexFunc(containers, func(container corev1.Container) bool {
+			return container.Name == containerName
+		})
+		if index < 0 {
+			return jobSpec, fmt.Errorf("container %s is not found in the job template", containerName)
+		}
+	}
+	if len(command) > 0 {
+		containers[index].Command = command
+		// The args of the template are no longer valid for the new command.
+		containers[index].Args = args
+	}
+	if len(args) > 0 {
+		containers[index].Args = args
+	}
+	return *newSpec, nil
+}
+
+func overrideImages(jobSpec batchv1.JobSpec, images map[string]string) (batchv1.JobSpec, error) {
+	if len(images) == 0 {
+		return jobSpec, nil
+	}
+
+	newSpec := jobSpec.DeepCopy()
+	for containerName, image := range images {
+		container := findContainer(newSpec.Template.Spec, containerName)
+		if container == nil {
+			return jobSpec, fmt.Errorf("container %s is not found in the job template", containerName)
+		}
+		container.Image = image
+	}
+	return *newSpec, nil
+}
+
+func limitActiveDeadlineSeconds(jobSpec batchv1.JobSpec, activeDeadlineSeconds *int64) batchv1.JobSpec {
+	if activeDeadlineSeconds == nil {
+		return jobSpec
+	}
+	if jobSpec.ActiveDeadlineSeconds != nil && *jobSpec.ActiveDeadlineSeconds <= *activeDeadlineSeconds {
+		return jobSpec
 	}
 	newSpec := jobSpec.DeepCopy()
-	newSpec.Template.Spec.Containers = newContainers
+	newSpec.ActiveDeadlineSeconds = ptr.To(*activeDeadlineSeconds)
 	return *newSpec
 }
 
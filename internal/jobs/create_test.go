@@ -39,7 +39,10 @@ func TestNewFromCronJob(t *testing.T) {
 				},
 			},
 		}
-		gotJob := NewFromCronJob(cronJob, nil, nil, nil)
+		gotJob, err := NewFromCronJob(cronJob, Options{})
+		if err != nil {
+			t.Fatalf("NewFromCronJob error: %s", err)
+		}
 		wantJob := &batchv1.Job{
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace:    "default",
@@ -92,7 +95,10 @@ func TestNewFromCronJob(t *testing.T) {
 				},
 			},
 		}
-		gotJob := NewFromCronJob(cronJob, map[string]string{"FOO": "bar"}, nil, nil)
+		gotJob, err := NewFromCronJob(cronJob, Options{Env: map[string]string{"FOO": "bar"}})
+		if err != nil {
+			t.Fatalf("NewFromCronJob error: %s", err)
+		}
 		wantJob := &batchv1.Job{
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace:    "default",
@@ -147,7 +153,13 @@ func TestNewFromCronJob(t *testing.T) {
 				},
 			},
 		}
-		gotJob := NewFromCronJob(cronJob, nil, map[string]string{"FOO": "bar"}, &corev1.LocalObjectReference{Name: "example-secret"})
+		gotJob, err := NewFromCronJob(cronJob, Options{
+			SecretEnv: map[string]string{"FOO": "bar"},
+			SecretRef: &corev1.LocalObjectReference{Name: "example-secret"},
+		})
+		if err != nil {
+			t.Fatalf("NewFromCronJob error: %s", err)
+		}
 		wantJob := &batchv1.Job{
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace:    "default",
@@ -205,7 +217,10 @@ func Test_appendEnv(t *testing.T) {
 	}
 
 	t.Run("do nothing if nil is given", func(t *testing.T) {
-		got := appendEnv(jobSpecWithEnv, nil)
+		got, err := appendEnv(jobSpecWithEnv, nil, nil)
+		if err != nil {
+			t.Fatalf("appendEnv error: %s", err)
+		}
 		want := jobSpecWithEnv
 		if diff := cmp.Diff(want, got); diff != "" {
 			t.Errorf("appendEnv() mismatch (-want +got):\n%s", diff)
@@ -213,7 +228,10 @@ func Test_appendEnv(t *testing.T) {
 	})
 
 	t.Run("map is appended to env of all containers", func(t *testing.T) {
-		got := appendEnv(jobSpecWithEnv, map[string]string{"BAZ": "qux"})
+		got, err := appendEnv(jobSpecWithEnv, map[string]string{"BAZ": "qux"}, nil)
+		if err != nil {
+			t.Fatalf("appendEnv error: %s", err)
+		}
 		want := batchv1.JobSpec{
 			Template: corev1.PodTemplateSpec{
 				Spec: corev1.PodSpec{
@@ -241,6 +259,76 @@ func Test_appendEnv(t *testing.T) {
 	})
 }
 
+func Test_appendContainerEnv(t *testing.T) {
+	jobSpec := batchv1.JobSpec{
+		Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					{Name: "init"},
+				},
+				Containers: []corev1.Container{
+					{Name: "container1"},
+					{Name: "container2"},
+				},
+			},
+		},
+	}
+	env := []corev1.EnvVar{{Name: "FOO", Value: "bar"}}
+
+	t.Run("env is appended to all containers except init containers", func(t *testing.T) {
+		got, err := appendContainerEnv(jobSpec, env, nil)
+		if err != nil {
+			t.Fatalf("appendContainerEnv error: %s", err)
+		}
+		want := batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{
+						{Name: "init"},
+					},
+					Containers: []corev1.Container{
+						{Name: "container1", Env: env},
+						{Name: "container2", Env: env},
+					},
+				},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("appendContainerEnv() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("env is appended to the selected containers", func(t *testing.T) {
+		got, err := appendContainerEnv(jobSpec, env, []string{"init", "container2"})
+		if err != nil {
+			t.Fatalf("appendContainerEnv error: %s", err)
+		}
+		want := batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{
+						{Name: "init", Env: env},
+					},
+					Containers: []corev1.Container{
+						{Name: "container1"},
+						{Name: "container2", Env: env},
+					},
+				},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("appendContainerEnv() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("container is not found", func(t *testing.T) {
+		_, err := appendContainerEnv(jobSpec, env, []string{"container3"})
+		if err == nil {
+			t.Errorf("appendContainerEnv() wants error but got nil")
+		}
+	})
+}
+
 func Test_appendSecretEnv(t *testing.T) {
 	jobSpecWithEnv := batchv1.JobSpec{
 		Template: corev1.PodTemplateSpec{
@@ -261,7 +349,10 @@ func Test_appendSecretEnv(t *testing.T) {
 	}
 
 	t.Run("do nothing if nil is given", func(t *testing.T) {
-		got := appendSecretEnv(jobSpecWithEnv, nil, nil)
+		got, err := appendSecretEnv(jobSpecWithEnv, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("appendSecretEnv error: %s", err)
+		}
 		want := jobSpecWithEnv
 		if diff := cmp.Diff(want, got); diff != "" {
 			t.Errorf("appendSecretEnv() mismatch (-want +got):\n%s", diff)
@@ -269,7 +360,10 @@ func Test_appendSecretEnv(t *testing.T) {
 	})
 
 	t.Run("do nothing if empty map is given", func(t *testing.T) {
-		got := appendSecretEnv(jobSpecWithEnv, map[string]string{}, nil)
+		got, err := appendSecretEnv(jobSpecWithEnv, map[string]string{}, nil, nil)
+		if err != nil {
+			t.Fatalf("appendSecretEnv error: %s", err)
+		}
 		want := jobSpecWithEnv
 		if diff := cmp.Diff(want, got); diff != "" {
 			t.Errorf("appendSecretEnv() mismatch (-want +got):\n%s", diff)
@@ -277,7 +371,10 @@ func Test_appendSecretEnv(t *testing.T) {
 	})
 
 	t.Run("do nothing if secretRef is nil", func(t *testing.T) {
-		got := appendSecretEnv(jobSpecWithEnv, map[string]string{"BAZ": "qux"}, nil)
+		got, err := appendSecretEnv(jobSpecWithEnv, map[string]string{"BAZ": "qux"}, nil, nil)
+		if err != nil {
+			t.Fatalf("appendSecretEnv error: %s", err)
+		}
 		want := jobSpecWithEnv
 		if diff := cmp.Diff(want, got); diff != "" {
 			t.Errorf("appendSecretEnv() mismatch (-want +got):\n%s", diff)
@@ -285,7 +382,10 @@ func Test_appendSecretEnv(t *testing.T) {
 	})
 
 	t.Run("map is appended to env of all containers", func(t *testing.T) {
-		got := appendSecretEnv(jobSpecWithEnv, map[string]string{"BAZ": "qux"}, &corev1.LocalObjectReference{Name: "example-secret"})
+		got, err := appendSecretEnv(jobSpecWithEnv, map[string]string{"BAZ": "qux"}, &corev1.LocalObjectReference{Name: "example-secret"}, nil)
+		if err != nil {
+			t.Fatalf("appendSecretEnv error: %s", err)
+		}
 		want := batchv1.JobSpec{
 			Template: corev1.PodTemplateSpec{
 				Spec: corev1.PodSpec{
@@ -328,3 +428,214 @@ func Test_appendSecretEnv(t *testing.T) {
 		}
 	})
 }
+
+func Test_overrideCommand(t *testing.T) {
+	jobSpec := batchv1.JobSpec{
+		Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name:    "container1",
+						Command: []string{"original-command1"},
+						Args:    []string{"original-args1"},
+					},
+					{
+						Name:    "container2",
+						Command: []string{"original-command2"},
+						Args:    []string{"original-args2"},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("do nothing if neither command nor args is given", func(t *testing.T) {
+		got, err := overrideCommand(jobSpec, "container2", nil, nil)
+		if err != nil {
+			t.Fatalf("overrideCommand error: %s", err)
+		}
+		if diff := cmp.Diff(jobSpec, got); diff != "" {
+			t.Errorf("overrideCommand() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("command and args of the first container are replaced", func(t *testing.T) {
+		got, err := overrideCommand(jobSpec, "", []string{"new-command"}, []string{"new-args"})
+		if err != nil {
+			t.Fatalf("overrideCommand error: %s", err)
+		}
+		want := batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:    "container1",
+							Command: []string{"new-command"},
+							Args:    []string{"new-args"},
+						},
+						{
+							Name:    "container2",
+							Command: []string{"original-command2"},
+							Args:    []string{"original-args2"},
+						},
+					},
+				},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("overrideCommand() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("args are cleared if only command is given", func(t *testing.T) {
+		got, err := overrideCommand(jobSpec, "container2", []string{"new-command"}, nil)
+		if err != nil {
+			t.Fatalf("overrideCommand error: %s", err)
+		}
+		want := batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:    "container1",
+							Command: []string{"original-command1"},
+							Args:    []string{"original-args1"},
+						},
+						{
+							Name:    "container2",
+							Command: []string{"new-command"},
+						},
+					},
+				},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("overrideCommand() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("only args are replaced if only args are given", func(t *testing.T) {
+		got, err := overrideCommand(jobSpec, "container2", nil, []string{"new-args"})
+		if err != nil {
+			t.Fatalf("overrideCommand error: %s", err)
+		}
+		want := batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:    "container1",
+							Command: []string{"original-command1"},
+							Args:    []string{"original-args1"},
+						},
+						{
+							Name:    "container2",
+							Command: []string{"original-command2"},
+							Args:    []string{"new-args"},
+						},
+					},
+				},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("overrideCommand() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("container is not found", func(t *testing.T) {
+		_, err := overrideCommand(jobSpec, "container3", []string{"new-command"}, nil)
+		if err == nil {
+			t.Errorf("overrideCommand() wants error but got nil")
+		}
+	})
+}
+
+func Test_overrideImages(t *testing.T) {
+	jobSpec := batchv1.JobSpec{
+		Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					{Name: "init", Image: "init:v1"},
+				},
+				Containers: []corev1.Container{
+					{Name: "container1", Image: "app:v1"},
+					{Name: "container2", Image: "proxy:v1"},
+				},
+			},
+		},
+	}
+
+	t.Run("do nothing if nil is given", func(t *testing.T) {
+		got, err := overrideImages(jobSpec, nil)
+		if err != nil {
+			t.Fatalf("overrideImages error: %s", err)
+		}
+		if diff := cmp.Diff(jobSpec, got); diff != "" {
+			t.Errorf("overrideImages() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("images of the containers are replaced", func(t *testing.T) {
+		got, err := overrideImages(jobSpec, map[string]string{"init": "init:v2", "container1": "app:v2"})
+		if err != nil {
+			t.Fatalf("overrideImages error: %s", err)
+		}
+		want := batchv1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{
+						{Name: "init", Image: "init:v2"},
+					},
+					Containers: []corev1.Container{
+						{Name: "container1", Image: "app:v2"},
+						{Name: "container2", Image: "proxy:v1"},
+					},
+				},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("overrideImages() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("container is not found", func(t *testing.T) {
+		_, err := overrideImages(jobSpec, map[string]string{"container3": "app:v2"})
+		if err == nil {
+			t.Errorf("overrideImages() wants error but got nil")
+		}
+	})
+}
+
+func Test_limitActiveDeadlineSeconds(t *testing.T) {
+	t.Run("do nothing if nil is given", func(t *testing.T) {
+		jobSpec := batchv1.JobSpec{ActiveDeadlineSeconds: ptr.To[int64](60)}
+		got := limitActiveDeadlineSeconds(jobSpec, nil)
+		if diff := cmp.Diff(jobSpec, got); diff != "" {
+			t.Errorf("limitActiveDeadlineSeconds() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("set if the template has no deadline", func(t *testing.T) {
+		got := limitActiveDeadlineSeconds(batchv1.JobSpec{}, ptr.To[int64](60))
+		want := batchv1.JobSpec{ActiveDeadlineSeconds: ptr.To[int64](60)}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("limitActiveDeadlineSeconds() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("set if the template has a longer deadline", func(t *testing.T) {
+		got := limitActiveDeadlineSeconds(batchv1.JobSpec{ActiveDeadlineSeconds: ptr.To[int64](3600)}, ptr.To[int64](60))
+		want := batchv1.JobSpec{ActiveDeadlineSeconds: ptr.To[int64](60)}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("limitActiveDeadlineSeconds() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("keep if the template has a stricter deadline", func(t *testing.T) {
+		jobSpec := batchv1.JobSpec{ActiveDeadlineSeconds: ptr.To[int64](30)}
+		got := limitActiveDeadlineSeconds(jobSpec, ptr.To[int64](60))
+		if diff := cmp.Diff(jobSpec, got); diff != "" {
+			t.Errorf("limitActiveDeadlineSeconds() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
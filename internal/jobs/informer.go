@@ -3,27 +3,55 @@ package jobs
 import (
 	"fmt"
 	"log/slog"
+	"reflect"
+	"sync"
 	"time"
 
+	"github.com/int128/cronjob-runner/internal/annotation"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 )
 
 type Informer interface {
+	// Shutdown implements informers.SharedInformerFactory#Shutdown
 	Shutdown()
+
+	// WaitForCacheSync implements informers.SharedInformerFactory#WaitForCacheSync
+	WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool
+
+	// Job returns the last known state of the job, or nil if not observed yet.
+	Job() *batchv1.Job
+}
+
+type informer struct {
+	informers.SharedInformerFactory
+	handler *eventHandler
+}
+
+func (i *informer) Job() *batchv1.Job {
+	i.handler.mu.Lock()
+	defer i.handler.mu.Unlock()
+	return i.handler.job
 }
 
 // StartInformer starts an informer to receive the change of job resource.
 // You must finally close stopCh to stop the informer.
-// When the job is completed or failed, it is sent to finishedCh.
+// When the watch is dropped by an error, it is sent to watchErrorHandler if set.
+// When the job is completed or failed, the condition is sent to finishedCh.
+// When the job is deleted, the last known state is sent to deletedCh.
+// When the job is failed, it is sent to annotator.
 func StartInformer(
 	clientset kubernetes.Interface,
 	namespace, jobName string,
 	stopCh <-chan struct{},
-	finishedCh chan<- batchv1.JobConditionType,
+	finishedCh chan<- batchv1.JobCondition,
+	deletedCh chan<- *batchv1.Job,
+	annotator annotation.Annotator,
+	watchErrorHandler cache.WatchErrorHandler,
 ) (Informer, error) {
 	informerFactory := informers.NewSharedInformerFactoryWithOptions(clientset, time.Hour*24,
 		informers.WithNamespace(namespace),
@@ -31,18 +59,37 @@ func StartInformer(
 			options.FieldSelector = fmt.Sprintf("metadata.name=%s", jobName)
 		}),
 	)
-	informer := informerFactory.Batch().V1().Jobs().Informer()
-	if _, err := informer.AddEventHandler(&eventHandler{finishedCh: finishedCh}); err != nil {
+	handler := &eventHandler{finishedCh: finishedCh, deletedCh: deletedCh, stopCh: stopCh, annotator: annotator}
+	sharedInformer := informerFactory.Batch().V1().Jobs().Informer()
+	if watchErrorHandler != nil {
+		if err := sharedInformer.SetWatchErrorHandler(watchErrorHandler); err != nil {
+			return nil, fmt.Errorf("set the watch error handler: %w", err)
+		}
+	}
+	if _, err := sharedInformer.AddEventHandler(handler); err != nil {
 		return nil, fmt.Errorf("add an event handler to the informer: %w", err)
 	}
 	informerFactory.Start(stopCh)
 	slog.Info("Watching Job",
 		slog.Group("job", slog.String("namespace", namespace), slog.String("name", jobName)))
-	return informerFactory, nil
+	return &informer{SharedInformerFactory: informerFactory, handler: handler}, nil
 }
 
 type eventHandler struct {
-	finishedCh chan<- batchv1.JobConditionType
+	finishedCh chan<- batchv1.JobCondition
+	deletedCh  chan<- *batchv1.Job
+	stopCh     <-chan struct{}
+	annotator  annotation.Annotator
+
+	mu sync.Mutex
+	// job is the last known state, including the deleted job.
+	job *batchv1.Job
+}
+
+func (h *eventHandler) recordJob(job *batchv1.Job) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.job = job
 }
 
 func (h *eventHandler) OnAdd(obj any, isInInitialList bool) {
@@ -50,19 +97,24 @@ func (h *eventHandler) OnAdd(obj any, isInInitialList bool) {
 	jobAttr := slog.Group("job", slog.String("namespace", job.Namespace), slog.String("name", job.Name))
 	if isInInitialList {
 		slog.Info("Job is found", jobAttr)
-		return
+	} else {
+		slog.Info("Job is created", jobAttr)
 	}
-	slog.Info("Job is created", jobAttr)
+	h.recordJob(job)
+	// The job may be already finished before the informer is started.
+	h.notifyConditionChange(&batchv1.Job{}, job)
+	notifyFinished(&batchv1.Job{}, job, h.finishedCh, h.stopCh)
 }
 
 func (h *eventHandler) OnUpdate(oldObj, newObj interface{}) {
 	oldJob := oldObj.(*batchv1.Job)
 	newJob := newObj.(*batchv1.Job)
-	notifyConditionChange(oldJob, newJob)
-	notifyFinished(oldJob, newJob, h.finishedCh)
+	h.recordJob(newJob)
+	h.notifyConditionChange(oldJob, newJob)
+	notifyFinished(oldJob, newJob, h.finishedCh, h.stopCh)
 }
 
-func notifyConditionChange(oldJob, newJob *batchv1.Job) {
+func (h *eventHandler) notifyConditionChange(oldJob, newJob *batchv1.Job) {
 	changedConditions := findChangedConditionsToTrue(oldJob.Status.Conditions, newJob.Status.Conditions)
 	jobAttr := slog.Group("job", slog.String("namespace", newJob.Namespace), slog.String("name", newJob.Name))
 	for conditionType, condition := range changedConditions {
@@ -73,6 +125,13 @@ func notifyConditionChange(oldJob, newJob *batchv1.Job) {
 			slog.Info("Job is failed", jobAttr,
 				slog.String("reason", condition.Reason),
 				slog.String("message", condition.Message))
+			if h.annotator != nil {
+				h.annotator.Annotate(annotation.Annotation{
+					Level:   annotation.LevelError,
+					Title:   fmt.Sprintf("Job %s is failed", newJob.Name),
+					Message: fmt.Sprintf("%s: %s", condition.Reason, condition.Message),
+				})
+			}
 		default:
 			slog.Info("Job condition is changed", jobAttr,
 				slog.Any("conditionType", condition.Type),
@@ -82,11 +141,15 @@ func notifyConditionChange(oldJob, newJob *batchv1.Job) {
 	}
 }
 
-func notifyFinished(oldJob, newJob *batchv1.Job, finishedCh chan<- batchv1.JobConditionType) {
+func notifyFinished(oldJob, newJob *batchv1.Job, finishedCh chan<- batchv1.JobCondition, stopCh <-chan struct{}) {
 	changedConditions := findChangedConditionsToTrue(oldJob.Status.Conditions, newJob.Status.Conditions)
-	for conditionType := range changedConditions {
+	for conditionType, condition := range changedConditions {
 		if conditionType == batchv1.JobComplete || conditionType == batchv1.JobFailed {
-			finishedCh <- conditionType
+			// Do not block if the receiver has already stopped.
+			select {
+			case finishedCh <- condition:
+			case <-stopCh:
+			}
 			return
 		}
 	}
@@ -115,7 +178,21 @@ func mapConditionByType(conditions []batchv1.JobCondition) map[batchv1.JobCondit
 }
 
 func (h *eventHandler) OnDelete(obj interface{}) {
-	job := obj.(*batchv1.Job)
+	// If the watch is disconnected, the informer receives a tombstone of the last known state.
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	job, ok := obj.(*batchv1.Job)
+	if !ok {
+		slog.Warn("Internal error: unexpected object on delete", "object", obj)
+		return
+	}
+	h.recordJob(job)
 	slog.Info("Job is deleted",
 		slog.Group("job", slog.String("namespace", job.Namespace), slog.String("name", job.Name)))
+	// Do not block if the receiver has already stopped.
+	select {
+	case h.deletedCh <- job:
+	case <-h.stopCh:
+	}
 }
@@ -0,0 +1,140 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/int128/cronjob-runner/internal/annotation"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestStartInformer(t *testing.T) {
+	t.Run("job is already completed", func(t *testing.T) {
+		clientset := fake.NewClientset(&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-job"},
+			Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{
+					{Type: batchv1.JobSuccessCriteriaMet, Status: corev1.ConditionTrue},
+					{Type: batchv1.JobComplete, Status: corev1.ConditionTrue},
+				},
+			},
+		})
+		got := receiveFinished(t, clientset)
+		want := batchv1.JobCondition{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("condition mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("job is already failed", func(t *testing.T) {
+		clientset := fake.NewClientset(&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-job"},
+			Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{
+					{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: batchv1.JobReasonBackoffLimitExceeded},
+				},
+			},
+		})
+		got := receiveFinished(t, clientset)
+		want := batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: batchv1.JobReasonBackoffLimitExceeded}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("condition mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("job is finished after the informer is started", func(t *testing.T) {
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-job"}}
+		clientset := fake.NewClientset(job)
+		stopCh := make(chan struct{})
+		finishedCh := make(chan batchv1.JobCondition)
+		informer, err := StartInformer(clientset, "default", "example-job", stopCh, finishedCh, make(chan *batchv1.Job), annotation.Nop{}, nil)
+		if err != nil {
+			t.Fatalf("StartInformer error: %s", err)
+		}
+		defer func() {
+			close(stopCh)
+			informer.Shutdown() // depends on close(stopCh)
+		}()
+
+		for typ, synced := range informer.WaitForCacheSync(stopCh) {
+			if !synced {
+				t.Fatalf("informer of %s is not synced", typ)
+			}
+		}
+		updatedJob := job.DeepCopy()
+		updatedJob.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		if _, err := clientset.BatchV1().Jobs("default").UpdateStatus(t.Context(), updatedJob, metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("UpdateStatus error: %s", err)
+		}
+
+		select {
+		case got := <-finishedCh:
+			if got.Type != batchv1.JobComplete {
+				t.Errorf("condition type wants %s but got %s", batchv1.JobComplete, got.Type)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for the finished condition")
+		}
+	})
+}
+
+func TestEventHandler_OnDelete(t *testing.T) {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-job"},
+		Status:     batchv1.JobStatus{Active: 1},
+	}
+
+	t.Run("job is deleted", func(t *testing.T) {
+		deletedCh := make(chan *batchv1.Job, 1)
+		h := &eventHandler{deletedCh: deletedCh}
+		h.OnDelete(job)
+		got := <-deletedCh
+		if diff := cmp.Diff(job, got); diff != "" {
+			t.Errorf("job mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("tombstone is received", func(t *testing.T) {
+		deletedCh := make(chan *batchv1.Job, 1)
+		h := &eventHandler{deletedCh: deletedCh}
+		h.OnDelete(cache.DeletedFinalStateUnknown{Key: "default/example-job", Obj: job})
+		got := <-deletedCh
+		if diff := cmp.Diff(job, got); diff != "" {
+			t.Errorf("job mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("receiver has already stopped", func(t *testing.T) {
+		stopCh := make(chan struct{})
+		close(stopCh)
+		h := &eventHandler{deletedCh: make(chan *batchv1.Job), stopCh: stopCh}
+		h.OnDelete(job)
+	})
+}
+
+func receiveFinished(t *testing.T, clientset *fake.Clientset) batchv1.JobCondition {
+	t.Helper()
+	stopCh := make(chan struct{})
+	finishedCh := make(chan batchv1.JobCondition)
+	informer, err := StartInformer(clientset, "default", "example-job", stopCh, finishedCh, make(chan *batchv1.Job), annotation.Nop{}, nil)
+	if err != nil {
+		t.Fatalf("StartInformer error: %s", err)
+	}
+	defer func() {
+		close(stopCh)
+		informer.Shutdown() // depends on close(stopCh)
+	}()
+
+	select {
+	case condition := <-finishedCh:
+		return condition
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the finished condition")
+		return batchv1.JobCondition{}
+	}
+}
@@ -2,78 +2,287 @@ package logs
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"strings"
 	"time"
 	"unicode"
+	"unicode/utf8"
 
+	"github.com/int128/cronjob-runner/internal/pods"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 )
 
 // Record represents a record of container logs.
 type Record struct {
-	RawTimestamp  string
+	RawTimestamp string
+
+	// Time is the parsed timestamp of the log line.
+	// It is zero if the timestamp cannot be parsed.
+	Time time.Time
+
 	Namespace     string
 	PodName       string
 	ContainerName string
+	ContainerType pods.ContainerType
+
+	// CompletionIndex is the completion index of an Indexed Job.
+	// It is nil if the Job is not indexed.
+	CompletionIndex *int
+
+	// PodRetryAttempt is the number of the failed Pods created before this Pod,
+	// for the same completion index if the Job is indexed.
+	PodRetryAttempt int
+
+	NodeName string
 
 	// Message is the log line.
 	// All trailing whitespaces are trimmed.
+	// If the line contains carriage returns, such as a progress bar,
+	// only the last segment is kept.
 	Message string
+
+	// Partial is true if the line exceeds the maximum size.
+	// The rest of the line follows in the next record(s).
+	Partial bool
+}
+
+// Source represents the container to tail.
+type Source struct {
+	Namespace       string
+	PodName         string
+	ContainerName   string
+	ContainerType   pods.ContainerType
+	CompletionIndex *int
+	PodRetryAttempt int
+	NodeName        string
 }
 
 type tailLogger interface {
 	Handle(record Record)
 }
 
+// TailOptions represents a set of options for Tail.
+type TailOptions struct {
+	// SinceSeconds is the relative time in seconds to start the log.
+	// It is applied to the first request only.
+	SinceSeconds *int64
+
+	// TailLines is the number of lines from the end of the log to start.
+	// It is applied to the first request only.
+	TailLines *int64
+
+	// MaxLineSize is the maximum size of a record in bytes.
+	// A longer line is split into the records with Partial.
+	// Default to DefaultMaxLineSize.
+	MaxLineSize int
+
+	// MaxRetryDuration is the maximum duration to retry on consecutive errors.
+	// The retry interval is increased exponentially with jitter.
+	// Default to DefaultMaxRetryDuration.
+	MaxRetryDuration time.Duration
+}
+
+// DefaultMaxLineSize is the default value of TailOptions.MaxLineSize.
+const DefaultMaxLineSize = 256 * 1024
+
+// DefaultMaxRetryDuration is the default value of TailOptions.MaxRetryDuration.
+const DefaultMaxRetryDuration = 5 * time.Minute
+
+// ErrStreamLost is set to StreamEnd.Err when the retry is exhausted.
+// The container log may be incomplete.
+var ErrStreamLost = errors.New("log stream lost")
+
+// StreamEnd represents the end of a container log stream.
+type StreamEnd struct {
+	Namespace     string
+	PodName       string
+	ContainerName string
+
+	// Err is the reason why the stream is stopped before EOF.
+	// It is nil if reached to EOF.
+	// It wraps ErrStreamLost if the retry is exhausted.
+	Err error
+}
+
+// streamEndHandler is an optional interface of tailLogger.
+type streamEndHandler interface {
+	HandleStreamEnd(end StreamEnd)
+}
+
 // Tail tails the container log until the following cases:
 //   - Reached to EOF
 //   - The Pod is not found (already removed from Node)
 //   - The context is canceled
-func Tail(ctx context.Context, clientset kubernetes.Interface, namespace, podName, containerName string, tlog tailLogger) {
+//   - The retry is exhausted
+//
+// If tlog implements HandleStreamEnd, it is called at the end.
+func Tail(ctx context.Context, clientset kubernetes.Interface, source Source, tlog tailLogger, opts TailOptions) {
 	logger := slog.With(
-		slog.Group("pod", slog.String("namespace", namespace), slog.String("name", podName)),
-		slog.Group("container", slog.String("name", containerName)),
+		slog.Group("pod", slog.String("namespace", source.Namespace), slog.String("name", source.PodName)),
+		slog.Group("container", slog.String("name", source.ContainerName)),
 	)
 	logger.Info("Tailing the container log")
-	var t tailer
+	t := tailer{
+		openStream: func(ctx context.Context, podLogOptions *corev1.PodLogOptions) (io.ReadCloser, error) {
+			return clientset.CoreV1().Pods(source.Namespace).GetLogs(source.PodName, podLogOptions).Stream(ctx)
+		},
+		sinceSeconds: opts.SinceSeconds,
+		tailLines:    opts.TailLines,
+		maxLineSize:  opts.MaxLineSize,
+	}
+	if t.maxLineSize <= 0 {
+		t.maxLineSize = DefaultMaxLineSize
+	}
+	maxRetryDuration := opts.MaxRetryDuration
+	if maxRetryDuration <= 0 {
+		maxRetryDuration = DefaultMaxRetryDuration
+	}
+	err := t.tail(ctx, source, tlog, logger, maxRetryDuration)
+	if h, ok := tlog.(streamEndHandler); ok {
+		h.HandleStreamEnd(StreamEnd{
+			Namespace:     source.Namespace,
+			PodName:       source.PodName,
+			ContainerName: source.ContainerName,
+			Err:           err,
+		})
+	}
+}
+
+// streamOpener opens a stream of the container log.
+type streamOpener func(ctx context.Context, podLogOptions *corev1.PodLogOptions) (io.ReadCloser, error)
+
+type tailer struct {
+	openStream streamOpener
+	// lastLogTime is the timestamp of the last record, or zero if not received yet.
+	lastLogTime  time.Time
+	sinceSeconds *int64
+	tailLines    *int64
+	maxLineSize  int
+	// received is the number of records received so far.
+	received int
+
+	// window is the records received in the same second of lastLogTime.
+	// Since the kubelet handles SinceTime in seconds, a resumed stream starts
+	// from the beginning of the second, and the records in the window are sent again.
+	window []windowEntry
+	// replaying is true until a new record is received in a resumed stream.
+	replaying bool
+	// replayIndex is the position in window to compare with a resumed stream.
+	replayIndex int
+}
+
+// maxWindowSize is the maximum number of records in the window.
+const maxWindowSize = 1000
+
+type windowEntry struct {
+	time         time.Time
+	rawTimestamp string
+	message      string
+	partial      bool
+}
+
+func newWindowEntry(record Record) windowEntry {
+	return windowEntry{
+		time:         record.Time,
+		rawTimestamp: record.RawTimestamp,
+		message:      record.Message,
+		partial:      record.Partial,
+	}
+}
+
+// matches returns true if the entry is the same record.
+// It does not compare the time, because rawTimestamp already identifies it
+// and time.Time cannot be compared by == across the locations.
+func (e windowEntry) matches(record Record) bool {
+	return e.rawTimestamp == record.RawTimestamp &&
+		e.message == record.Message &&
+		e.partial == record.Partial
+}
+
+// newRetryBackoff returns the backoff of retry on consecutive errors.
+func newRetryBackoff() wait.Backoff {
+	return wait.Backoff{
+		Duration: 100 * time.Millisecond,
+		Factor:   2,
+		Jitter:   0.5,
+		Steps:    math.MaxInt32,
+		Cap:      30 * time.Second,
+	}
+}
+
+// tail resumes the stream until EOF or a permanent error.
+// It returns nil if reached to EOF.
+func (t *tailer) tail(ctx context.Context, source Source, tlog tailLogger, logger *slog.Logger, maxRetryDuration time.Duration) error {
+	backoff := newRetryBackoff()
+	var firstErrorTime time.Time
 	for {
-		err := t.resume(ctx, clientset, namespace, podName, containerName, tlog)
+		received := t.received
+		err := t.resume(ctx, source, tlog)
 		if err == nil {
-			return
+			return nil
 		}
 		if kerrors.IsNotFound(err) {
 			logger.Warn("Pod was deleted before reached to EOF of the container log", "error", err)
-			return
+			return err
 		}
 		if errors.Is(err, context.Canceled) {
 			logger.Warn("Stopped tailing the container log before reached to EOF", "error", err)
-			return
+			return err
+		}
+		if t.received > received || firstErrorTime.IsZero() {
+			// Reset the backoff if the stream made progress.
+			backoff = newRetryBackoff()
+			firstErrorTime = time.Now()
+		}
+		if time.Since(firstErrorTime) > maxRetryDuration {
+			logger.Error("Lost the container log stream. The log may be incomplete", "error", err)
+			return fmt.Errorf("%w: %w", ErrStreamLost, err)
+		}
+		interval := backoff.Step()
+		logger.Warn("Retrying to tail the container log", "error", err, "interval", interval)
+		if err := sleep(ctx, interval); err != nil {
+			logger.Warn("Stopped tailing the container log before reached to EOF", "error", err)
+			return err
 		}
-		logger.Warn("Retrying to tail the container log", "error", err)
-		time.Sleep(100 * time.Millisecond)
 	}
 }
 
-type tailer struct {
-	lastLogTime *metav1.Time
+// sleep waits for the duration or until the context is canceled.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func (t *tailer) resume(ctx context.Context, clientset kubernetes.Interface, namespace, podName, containerName string, tlog tailLogger) error {
-	stream, err := clientset.CoreV1().Pods(namespace).GetLogs(podName, &corev1.PodLogOptions{
-		Container: containerName,
+func (t *tailer) resume(ctx context.Context, source Source, tlog tailLogger) error {
+	podLogOptions := &corev1.PodLogOptions{
+		Container: source.ContainerName,
 		Follow:    true,
 		// Get the timestamp to resume from the last point when the connection is lost.
 		Timestamps: true,
-		SinceTime:  t.lastLogTime,
-	}).Stream(ctx)
+	}
+	if !t.lastLogTime.IsZero() {
+		podLogOptions.SinceTime = &metav1.Time{Time: t.lastLogTime}
+	} else {
+		podLogOptions.SinceSeconds = t.sinceSeconds
+		podLogOptions.TailLines = t.tailLines
+	}
+	stream, err := t.openStream(ctx, podLogOptions)
 	if err != nil {
 		return fmt.Errorf("stream error: %w", err)
 	}
@@ -83,19 +292,52 @@ func (t *tailer) resume(ctx context.Context, clientset kubernetes.Interface, nam
 		}
 	}()
 
-	reader := bufio.NewReader(stream)
+	t.replaying, t.replayIndex = len(t.window) > 0, 0
+	return t.read(stream, source, tlog)
+}
+
+// read reads the stream until EOF.
+// If a line exceeds maxLineSize, it is split into the records with Partial.
+func (t *tailer) read(stream io.Reader, source Source, tlog tailLogger) error {
+	reader := bufio.NewReaderSize(stream, t.maxLineSize)
+	var (
+		// continuing is true if the previous chunk is a part of the current line.
+		continuing   bool
+		rawTimestamp string
+		logTime      time.Time
+		// carry is an incomplete UTF-8 sequence at the end of the previous chunk.
+		carry []byte
+	)
 	for {
-		line, err := reader.ReadString('\n')
-		if line != "" {
-			rawTimestamp, metaTime, message := parseLine(line)
-			tlog.Handle(Record{
-				RawTimestamp:  rawTimestamp,
-				Namespace:     namespace,
-				PodName:       podName,
-				ContainerName: containerName,
-				Message:       message,
-			})
-			t.lastLogTime = metaTime
+		chunk, err := reader.ReadSlice('\n')
+		partial := errors.Is(err, bufio.ErrBufferFull)
+		if len(chunk) > 0 {
+			body := chunk
+			if !continuing {
+				rawTimestamp, logTime, body = parseTimestamp(chunk)
+			}
+			// Avoid copying the whole chunk to prepend the carry.
+			head := carry
+			carry = nil
+			if partial {
+				if len(body) < utf8.UTFMax {
+					body = append(head, body...)
+					head = nil
+				}
+				n := completeRunesLen(body)
+				carry = bytes.Clone(body[n:])
+				body = body[:n]
+			} else {
+				body = bytes.TrimRightFunc(body, unicode.IsSpace)
+			}
+			record := source.newRecord(rawTimestamp, messageOf(head, body))
+			record.Time = logTime
+			record.Partial = partial
+			t.handle(record, tlog)
+			continuing = partial
+		}
+		if partial {
+			continue
 		}
 		if err == io.EOF {
 			return nil
@@ -106,21 +348,104 @@ func (t *tailer) resume(ctx context.Context, clientset kubernetes.Interface, nam
 	}
 }
 
-// parseLine parses the line and returns the timestamp and message.
-// It trims all trailing whitespaces in the line.
+// handle sends the record to tlog, except the records already sent before resuming.
+func (t *tailer) handle(record Record, tlog tailLogger) {
+	if t.isReplayed(record) {
+		return
+	}
+	t.replaying = false
+	t.remember(record)
+	tlog.Handle(record)
+	t.received++
+	if !record.Time.IsZero() {
+		t.lastLogTime = record.Time
+	}
+}
+
+// isReplayed returns true if the record has already been sent before resuming.
+func (t *tailer) isReplayed(record Record) bool {
+	if !t.replaying {
+		return false
+	}
+	// The records before the window have already been sent.
+	if !record.Time.IsZero() && record.Time.Before(t.window[0].time) {
+		return true
+	}
+	if t.replayIndex < len(t.window) && t.window[t.replayIndex].matches(record) {
+		t.replayIndex++
+		return true
+	}
+	return false
+}
+
+// remember adds the record to the window.
+func (t *tailer) remember(record Record) {
+	if len(t.window) > 0 && !record.Time.Truncate(time.Second).Equal(t.window[0].time.Truncate(time.Second)) {
+		t.window = t.window[:0]
+	}
+	if len(t.window) >= maxWindowSize {
+		t.window = t.window[1:]
+	}
+	t.window = append(t.window, newWindowEntry(record))
+}
+
+func (s Source) newRecord(rawTimestamp, message string) Record {
+	return Record{
+		RawTimestamp:    rawTimestamp,
+		Namespace:       s.Namespace,
+		PodName:         s.PodName,
+		ContainerName:   s.ContainerName,
+		ContainerType:   s.ContainerType,
+		CompletionIndex: s.CompletionIndex,
+		PodRetryAttempt: s.PodRetryAttempt,
+		NodeName:        s.NodeName,
+		Message:         message,
+	}
+}
+
+// parseTimestamp parses the timestamp at the beginning of the line.
+// It returns the timestamp and the rest of the line.
 // If it cannot parse the timestamp, it returns the whole line.
-func parseLine(line string) (string, *metav1.Time, string) {
-	trimmedLine := strings.TrimRightFunc(line, unicode.IsSpace)
-	s := strings.SplitN(trimmedLine, " ", 2)
-	if len(s) != 2 {
-		return "", nil, trimmedLine
+func parseTimestamp(line []byte) (string, time.Time, []byte) {
+	i := bytes.IndexByte(line, ' ')
+	if i < 0 {
+		return "", time.Time{}, line
 	}
-	rawTimestamp, message := s[0], s[1]
+	rawTimestamp := string(line[:i])
 	t, err := time.Parse(time.RFC3339, rawTimestamp)
 	if err != nil {
 		slog.Debug("Internal error: invalid log timestamp", "error", err, "rawTimestamp", rawTimestamp)
-		return "", nil, trimmedLine
+		return "", time.Time{}, line
+	}
+	return rawTimestamp, t, line[i+1:]
+}
+
+// messageOf returns the concatenation of head and body as a string.
+// If body contains carriage returns, it returns the last segment only,
+// because a progress bar usually overwrites the line and a terminal shows the last segment.
+func messageOf(head, body []byte) string {
+	if i := bytes.LastIndexByte(body, '\r'); i >= 0 {
+		return string(body[i+1:])
+	}
+	if len(head) == 0 {
+		return string(body)
+	}
+	var b strings.Builder
+	b.Grow(len(head) + len(body))
+	b.Write(head)
+	b.Write(body)
+	return b.String()
+}
+
+// completeRunesLen returns the length of b without an incomplete UTF-8 sequence at the end.
+func completeRunesLen(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if utf8.FullRune(b[i:]) {
+				return len(b)
+			}
+			return i
+		}
 	}
-	metaTime := metav1.NewTime(t)
-	return rawTimestamp, &metaTime, message
+	return len(b)
 }
@@ -0,0 +1,254 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type recorder struct {
+	records []Record
+}
+
+func (r *recorder) Handle(record Record) {
+	r.records = append(r.records, record)
+}
+
+func TestTailer_read(t *testing.T) {
+	const ts = "2024-03-23T05:29:26.862529Z"
+	for name, tc := range map[string]struct {
+		maxLineSize int
+		stream      string
+		want        []Record
+	}{
+		"lines": {
+			stream: ts + " hello\n" + ts + " \n" + ts + " world  \n",
+			want: []Record{
+				{RawTimestamp: ts, Message: "hello"},
+				{RawTimestamp: ts, Message: ""},
+				{RawTimestamp: ts, Message: "world"},
+			},
+		},
+		"no newline at EOF": {
+			stream: ts + " hello",
+			want:   []Record{{RawTimestamp: ts, Message: "hello"}},
+		},
+		"no timestamp": {
+			stream: "hello world\n",
+			want:   []Record{{Message: "hello world"}},
+		},
+		"carriage return": {
+			stream: ts + " 10%\r50%\r100%\r\n",
+			want:   []Record{{RawTimestamp: ts, Message: "100%"}},
+		},
+		"long line": {
+			maxLineSize: 40,
+			stream:      ts + " " + strings.Repeat("a", 20) + strings.Repeat("b", 40) + "c\n" + ts + " next\n",
+			want: []Record{
+				{RawTimestamp: ts, Message: strings.Repeat("a", 12), Partial: true},
+				{RawTimestamp: ts, Message: strings.Repeat("a", 8) + strings.Repeat("b", 32), Partial: true},
+				{RawTimestamp: ts, Message: strings.Repeat("b", 8) + "c"},
+				{RawTimestamp: ts, Message: "next"},
+			},
+		},
+		"multibyte rune at the boundary": {
+			maxLineSize: 32,
+			// The buffer ends in the middle of "あ" (3 bytes).
+			stream: ts + " " + "ああ\n",
+			want: []Record{
+				{RawTimestamp: ts, Message: "あ", Partial: true},
+				{RawTimestamp: ts, Message: "あ"},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tr := tailer{maxLineSize: tc.maxLineSize}
+			if tr.maxLineSize == 0 {
+				tr.maxLineSize = DefaultMaxLineSize
+			}
+			var r recorder
+			if err := tr.read(strings.NewReader(tc.stream), Source{}, &r); err != nil {
+				t.Fatalf("read error: %s", err)
+			}
+			if diff := cmp.Diff(tc.want, r.records, cmpopts.IgnoreFields(Record{}, "Time")); diff != "" {
+				t.Errorf("records mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// repeatReader reads the data repeatedly until the size.
+type repeatReader struct {
+	data []byte
+	pos  int
+	size int64
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	if r.size <= 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data[r.pos:])
+	if int64(n) > r.size {
+		n = int(r.size)
+	}
+	r.pos = (r.pos + n) % len(r.data)
+	r.size -= int64(n)
+	return n, nil
+}
+
+type discardTailLogger struct{}
+
+func (discardTailLogger) Handle(Record) {}
+
+func BenchmarkTailer_read(b *testing.B) {
+	const streamSize = 2 << 30 // 2 GiB
+	for name, line := range map[string][]byte{
+		"short lines": []byte("2024-03-23T05:29:26.862529Z " + strings.Repeat("x", 100) + "\n"),
+		"long lines":  []byte("2024-03-23T05:29:26.862529Z " + strings.Repeat("x", 10<<20) + "\n"),
+		"binary":      bytes.Repeat([]byte{0x00, 0xff, 0x7f, 0xe3}, 1<<20),
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(streamSize)
+			b.ReportAllocs()
+			for b.Loop() {
+				tr := tailer{maxLineSize: DefaultMaxLineSize}
+				if err := tr.read(&repeatReader{data: line, size: streamSize}, Source{}, discardTailLogger{}); err != nil {
+					b.Fatalf("read error: %s", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSleep(t *testing.T) {
+	t.Run("elapsed", func(t *testing.T) {
+		if err := sleep(t.Context(), time.Millisecond); err != nil {
+			t.Errorf("sleep wants nil but got %s", err)
+		}
+	})
+	t.Run("context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		if err := sleep(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+			t.Errorf("sleep wants context.Canceled but got %v", err)
+		}
+	})
+}
+
+// fakeStreamOpener returns the streams in order.
+// Each stream returns the data and then the error.
+type fakeStreamOpener struct {
+	streams []fakeStream
+	got     []*corev1.PodLogOptions
+}
+
+type fakeStream struct {
+	data string
+	err  error
+}
+
+func (f *fakeStreamOpener) open(_ context.Context, podLogOptions *corev1.PodLogOptions) (io.ReadCloser, error) {
+	f.got = append(f.got, podLogOptions)
+	if len(f.got) > len(f.streams) {
+		return nil, errors.New("no more stream")
+	}
+	s := f.streams[len(f.got)-1]
+	r := io.Reader(strings.NewReader(s.data))
+	if s.err != nil {
+		r = io.MultiReader(r, iotest.ErrReader(s.err))
+	}
+	return io.NopCloser(r), nil
+}
+
+func TestTailer_tail(t *testing.T) {
+	t.Run("resume the broken stream", func(t *testing.T) {
+		opener := &fakeStreamOpener{
+			streams: []fakeStream{
+				{
+					data: "2024-03-23T05:29:25.000000001Z first\n" +
+						"2024-03-23T05:29:26.000000001Z a\n" +
+						"2024-03-23T05:29:26.500000001Z same\n" +
+						"2024-03-23T05:29:26.500000001Z same\n",
+					err: errors.New("connection reset"),
+				},
+				{
+					// The kubelet returns the records since the beginning of the second.
+					data: "2024-03-23T05:29:26.000000001Z a\n" +
+						"2024-03-23T05:29:26.500000001Z same\n" +
+						"2024-03-23T05:29:26.500000001Z same\n" +
+						"2024-03-23T05:29:26.500000001Z same\n" +
+						"2024-03-23T05:29:27.000000001Z b\n",
+				},
+			},
+		}
+		tr := tailer{openStream: opener.open, maxLineSize: DefaultMaxLineSize}
+		var r recorder
+		if err := tr.tail(t.Context(), Source{}, &r, slog.Default(), time.Minute); err != nil {
+			t.Fatalf("tail error: %s", err)
+		}
+		var got []string
+		for _, record := range r.records {
+			got = append(got, record.Message)
+		}
+		want := []string{"first", "a", "same", "same", "same", "b"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("messages mismatch (-want +got):\n%s", diff)
+		}
+		if len(opener.got) != 2 {
+			t.Fatalf("stream must be opened twice but got %d", len(opener.got))
+		}
+		wantSinceTime := time.Date(2024, 3, 23, 5, 29, 26, 500000001, time.UTC)
+		if since := opener.got[1].SinceTime; since == nil || !since.Time.Equal(wantSinceTime) {
+			t.Errorf("SinceTime wants %s but got %v", wantSinceTime, since)
+		}
+	})
+
+	t.Run("resume the broken stream with non-UTC timestamps", func(t *testing.T) {
+		opener := &fakeStreamOpener{
+			streams: []fakeStream{
+				{
+					data: "2024-03-23T11:14:26.000000001+05:45 a\n",
+					err:  errors.New("connection reset"),
+				},
+				{
+					data: "2024-03-23T11:14:26.000000001+05:45 a\n" +
+						"2024-03-23T11:14:27.000000001+05:45 b\n",
+				},
+			},
+		}
+		tr := tailer{openStream: opener.open, maxLineSize: DefaultMaxLineSize}
+		var r recorder
+		if err := tr.tail(t.Context(), Source{}, &r, slog.Default(), time.Minute); err != nil {
+			t.Fatalf("tail error: %s", err)
+		}
+		var got []string
+		for _, record := range r.records {
+			got = append(got, record.Message)
+		}
+		want := []string{"a", "b"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("messages mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("retry is exhausted", func(t *testing.T) {
+		opener := &fakeStreamOpener{}
+		tr := tailer{openStream: opener.open, maxLineSize: DefaultMaxLineSize}
+		var r recorder
+		err := tr.tail(t.Context(), Source{}, &r, slog.Default(), time.Millisecond)
+		if !errors.Is(err, ErrStreamLost) {
+			t.Errorf("tail wants ErrStreamLost but got %v", err)
+		}
+	})
+}
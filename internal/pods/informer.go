@@ -1,19 +1,98 @@
 package pods
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/int128/cronjob-runner/internal/annotation"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
 )
 
 type Informer interface {
 	// Shutdown implements informers.SharedInformerFactory#Shutdown
 	Shutdown()
+
+	// WaitForCacheSync implements informers.SharedInformerFactory#WaitForCacheSync
+	WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool
+
+	// TerminatedContainers returns the terminated states of containers observed by the informer.
+	TerminatedContainers() []ContainerTerminatedState
+
+	// IsJobPod returns true if the pod belongs to the job.
+	// If the pod has not been observed by the informer yet, it gets the pod from the API server
+	// and checks the job-name label.
+	IsJobPod(podName string) bool
+
+	// Pods returns the last known state of the pods in order of creation, including deleted pods.
+	Pods() []*corev1.Pod
+}
+
+type informer struct {
+	informers.SharedInformerFactory
+	handler   *eventHandler
+	clientset kubernetes.Interface
+	namespace string
+	jobName   string
+
+	mu sync.Mutex
+	// jobPods is a map of pod name to whether it belongs to the job, checked via the API server.
+	jobPods map[string]bool
+}
+
+func (i *informer) TerminatedContainers() []ContainerTerminatedState {
+	return i.handler.terminatedContainers()
+}
+
+// getPodTimeout is the timeout to get a pod in IsJobPod.
+const getPodTimeout = 10 * time.Second
+
+func (i *informer) IsJobPod(podName string) bool {
+	if i.handler.hasPod(podName) {
+		return true
+	}
+	// The name of a pod is generated from the job name.
+	if !strings.HasPrefix(podName, i.jobName+"-") {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if isJobPod, ok := i.jobPods[podName]; ok {
+		return isJobPod
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), getPodTimeout)
+	defer cancel()
+	pod, err := i.clientset.CoreV1().Pods(i.namespace).Get(ctx, podName, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		// The pod has been deleted before the informer is started.
+		i.jobPods[podName] = false
+		return false
+	}
+	if err != nil {
+		slog.Debug("Could not get the pod", "error", err,
+			slog.Group("pod", slog.String("namespace", i.namespace), slog.String("name", podName)))
+		return false
+	}
+	isJobPod := pod.Labels[batchv1.JobNameLabel] == i.jobName
+	i.jobPods[podName] = isJobPod
+	return isJobPod
+}
+
+func (i *informer) Pods() []*corev1.Pod {
+	return i.handler.knownPods()
 }
 
 // ContainerStartedEvent is sent when a container is started.
@@ -21,17 +100,56 @@ type ContainerStartedEvent struct {
 	Namespace     string
 	PodName       string
 	ContainerName string
+	ContainerType ContainerType
+
+	// CompletionIndex is the completion index of an Indexed Job.
+	// It is nil if the Job is not indexed.
+	CompletionIndex *int
+
+	// PodRetryAttempt is the number of the failed Pods created before this Pod,
+	// for the same completion index if the Job is indexed.
+	// It starts from 0.
+	PodRetryAttempt int
+
+	NodeName string
+}
+
+// ContainerType represents the type of a container.
+type ContainerType string
+
+const (
+	ContainerTypeInit    ContainerType = "init"
+	ContainerTypeSidecar ContainerType = "sidecar"
+	ContainerTypeMain    ContainerType = "main"
+)
+
+// ContainerTerminatedState represents the terminated state of a container.
+type ContainerTerminatedState struct {
+	PodName       string
+	ContainerName string
+
+	// InitContainer is true if the container is an init container.
+	InitContainer bool
+
+	ExitCode   int32
+	Reason     string
+	Message    string
+	FinishedAt time.Time
 }
 
 // StartInformer an informer to receive the change of pod resource.
 // It finds the corresponding pod(s) by job name.
 // You must finally close stopCh to stop the informer.
+// When the watch is dropped by an error, it is sent to watchErrorHandler if set.
 // When the status of container is changed, the event is sent to containerStartedCh.
+// When a problem occurs, such as a container failure, it is sent to annotator.
 func StartInformer(
 	clientset kubernetes.Interface,
 	namespace, jobName string,
 	stopCh <-chan struct{},
 	containerStartedCh chan<- ContainerStartedEvent,
+	annotator annotation.Annotator,
+	watchErrorHandler cache.WatchErrorHandler,
 ) (Informer, error) {
 	informerFactory := informers.NewSharedInformerFactoryWithOptions(clientset, time.Hour*24,
 		informers.WithNamespace(namespace),
@@ -39,18 +157,81 @@ func StartInformer(
 			options.LabelSelector = fmt.Sprintf("batch.kubernetes.io/job-name=%s", jobName)
 		}),
 	)
-	informer := informerFactory.Core().V1().Pods().Informer()
-	if _, err := informer.AddEventHandler(&eventHandler{containerStartedCh: containerStartedCh}); err != nil {
+	handler := &eventHandler{containerStartedCh: containerStartedCh, annotator: annotator}
+	sharedInformer := informerFactory.Core().V1().Pods().Informer()
+	if watchErrorHandler != nil {
+		if err := sharedInformer.SetWatchErrorHandler(watchErrorHandler); err != nil {
+			return nil, fmt.Errorf("set the watch error handler: %w", err)
+		}
+	}
+	if _, err := sharedInformer.AddEventHandler(handler); err != nil {
 		return nil, fmt.Errorf("add an event handler to the informer: %w", err)
 	}
 	informerFactory.Start(stopCh)
 	slog.Info("Watching Pod",
 		slog.Group("job", slog.String("namespace", namespace), slog.String("name", jobName)))
-	return informerFactory, nil
+	return &informer{
+		SharedInformerFactory: informerFactory,
+		handler:               handler,
+		clientset:             clientset,
+		namespace:             namespace,
+		jobName:               jobName,
+		jobPods:               make(map[string]bool),
+	}, nil
 }
 
 type eventHandler struct {
 	containerStartedCh chan<- ContainerStartedEvent
+	annotator          annotation.Annotator
+
+	mu         sync.Mutex
+	terminated []ContainerTerminatedState
+	// pods is a map of pod name to the last known state, including deleted pods.
+	pods map[string]*corev1.Pod
+}
+
+func (h *eventHandler) terminatedContainers() []ContainerTerminatedState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]ContainerTerminatedState(nil), h.terminated...)
+}
+
+func (h *eventHandler) hasPod(podName string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.pods[podName]
+	return ok
+}
+
+func (h *eventHandler) knownPods() []*corev1.Pod {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	pods := make([]*corev1.Pod, 0, len(h.pods))
+	for _, pod := range h.pods {
+		pods = append(pods, pod)
+	}
+	slices.SortFunc(pods, func(a, b *corev1.Pod) int {
+		if c := a.CreationTimestamp.Compare(b.CreationTimestamp.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return pods
+}
+
+func (h *eventHandler) annotate(a annotation.Annotation) {
+	if h.annotator != nil {
+		h.annotator.Annotate(a)
+	}
+}
+
+func (h *eventHandler) recordPod(pod *corev1.Pod) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.pods == nil {
+		h.pods = make(map[string]*corev1.Pod)
+	}
+	h.pods[pod.Name] = pod
 }
 
 func (h *eventHandler) OnAdd(obj interface{}, isInInitialList bool) {
@@ -58,21 +239,33 @@ func (h *eventHandler) OnAdd(obj interface{}, isInInitialList bool) {
 	podAttr := slog.Group("pod", slog.String("namespace", pod.Namespace), slog.String("name", pod.Name), slog.Any("phase", pod.Status.Phase))
 	if isInInitialList {
 		slog.Info("Pod is found", podAttr)
-		return
+	} else {
+		slog.Info("Pod is created", podAttr)
 	}
-	slog.Info("Pod is created", podAttr)
+	h.recordPod(pod)
+	// If the pod already exists, such as attaching to a running Job or relisting,
+	// the containers may be running or terminated.
+	h.notifyContainerStatusChanges(pod.Namespace, pod.Name, nil, pod.Status.InitContainerStatuses)
+	h.notifyContainerStatusChanges(pod.Namespace, pod.Name, nil, pod.Status.ContainerStatuses)
+	h.notifyContainerStarted(pod, true, nil, pod.Status.InitContainerStatuses)
+	h.notifyContainerStarted(pod, false, nil, pod.Status.ContainerStatuses)
+	h.recordContainerTerminated(pod.Name, true, nil, pod.Status.InitContainerStatuses)
+	h.recordContainerTerminated(pod.Name, false, nil, pod.Status.ContainerStatuses)
 }
 
 func (h *eventHandler) OnUpdate(oldObj, newObj interface{}) {
 	oldPod := oldObj.(*corev1.Pod)
 	newPod := newObj.(*corev1.Pod)
+	h.recordPod(newPod)
 	h.notifyPodStatusChange(oldPod, newPod)
 	h.notifyPodConditionScheduled(oldPod, newPod)
 	h.notifyPodConditionDisruptionTarget(oldPod, newPod)
 	h.notifyContainerStatusChanges(newPod.Namespace, newPod.Name, oldPod.Status.InitContainerStatuses, newPod.Status.InitContainerStatuses)
 	h.notifyContainerStatusChanges(newPod.Namespace, newPod.Name, oldPod.Status.ContainerStatuses, newPod.Status.ContainerStatuses)
-	h.notifyContainerStarted(newPod.Namespace, newPod.Name, oldPod.Status.InitContainerStatuses, newPod.Status.InitContainerStatuses)
-	h.notifyContainerStarted(newPod.Namespace, newPod.Name, oldPod.Status.ContainerStatuses, newPod.Status.ContainerStatuses)
+	h.notifyContainerStarted(newPod, true, oldPod.Status.InitContainerStatuses, newPod.Status.InitContainerStatuses)
+	h.notifyContainerStarted(newPod, false, oldPod.Status.ContainerStatuses, newPod.Status.ContainerStatuses)
+	h.recordContainerTerminated(newPod.Name, true, oldPod.Status.InitContainerStatuses, newPod.Status.InitContainerStatuses)
+	h.recordContainerTerminated(newPod.Name, false, oldPod.Status.ContainerStatuses, newPod.Status.ContainerStatuses)
 }
 
 func (h *eventHandler) notifyPodStatusChange(oldPod, newPod *corev1.Pod) {
@@ -90,6 +283,11 @@ func (h *eventHandler) notifyPodStatusChange(oldPod, newPod *corev1.Pod) {
 			slog.String("reason", newPod.Status.Reason),
 			slog.String("message", newPod.Status.Message),
 		)
+		h.annotate(annotation.Annotation{
+			Level:   annotation.LevelError,
+			Title:   fmt.Sprintf("Pod %s is failed", newPod.Name),
+			Message: reasonMessage(newPod.Status.Reason, newPod.Status.Message),
+		})
 	default:
 		slog.Info("Pod phase is changed", podAttr,
 			slog.String("reason", newPod.Status.Reason),
@@ -108,6 +306,13 @@ func (h *eventHandler) notifyPodConditionScheduled(oldPod, newPod *corev1.Pod) {
 		slog.Info("Pod is scheduling", podAttr,
 			slog.String("reason", condition.Reason),
 			slog.String("message", condition.Message))
+		if condition.Reason == corev1.PodReasonUnschedulable {
+			h.annotate(annotation.Annotation{
+				Level:   annotation.LevelWarning,
+				Title:   fmt.Sprintf("Pod %s is unschedulable", newPod.Name),
+				Message: condition.Message,
+			})
+		}
 	}
 }
 
@@ -118,7 +323,20 @@ func (h *eventHandler) notifyPodConditionDisruptionTarget(oldPod, newPod *corev1
 		slog.Info("Pod will be terminated due to a disruption", podAttr,
 			slog.String("reason", condition.Reason),
 			slog.String("message", condition.Message))
+		h.annotate(annotation.Annotation{
+			Level:   annotation.LevelWarning,
+			Title:   fmt.Sprintf("Pod %s will be terminated due to a disruption", newPod.Name),
+			Message: reasonMessage(condition.Reason, condition.Message),
+		})
+	}
+}
+
+// reasonMessage returns the reason and message in a line.
+func reasonMessage(reason, message string) string {
+	if message == "" {
+		return reason
 	}
+	return fmt.Sprintf("%s: %s", reason, message)
 }
 
 func findChangedPodConditionByType(conditionType corev1.PodConditionType, oldConditions, newConditions []corev1.PodCondition) corev1.PodCondition {
@@ -160,11 +378,18 @@ func (h *eventHandler) notifyContainerStatusChanges(namespace, podName string, o
 				slog.String("reason", terminated.Reason),
 				slog.String("message", terminated.Message),
 			)
+			if terminated.ExitCode != 0 {
+				h.annotate(annotation.Annotation{
+					Level:   annotation.LevelError,
+					Title:   fmt.Sprintf("Container %s/%s exited with code %d", podName, change.newStatus.Name, terminated.ExitCode),
+					Message: reasonMessage(terminated.Reason, terminated.Message),
+				})
+			}
 		}
 	}
 }
 
-func (h *eventHandler) notifyContainerStarted(namespace, podName string, oldStatuses, newStatuses []corev1.ContainerStatus) {
+func (h *eventHandler) notifyContainerStarted(pod *corev1.Pod, initContainer bool, oldStatuses, newStatuses []corev1.ContainerStatus) {
 	containerStateChanges := computeContainerStateChanges(oldStatuses, newStatuses)
 	for _, change := range containerStateChanges {
 		oldState := getContainerState(change.oldStatus)
@@ -176,14 +401,87 @@ func (h *eventHandler) notifyContainerStarted(namespace, podName string, oldStat
 		if (oldState == containerStateWaiting && newState != containerStateWaiting) ||
 			(oldState == containerStateTerminated && newState == containerStateRunning) {
 			h.containerStartedCh <- ContainerStartedEvent{
-				Namespace:     namespace,
-				PodName:       podName,
-				ContainerName: change.newStatus.Name,
+				Namespace:       pod.Namespace,
+				PodName:         pod.Name,
+				ContainerName:   change.newStatus.Name,
+				ContainerType:   ContainerTypeOf(pod, initContainer, change.newStatus.Name),
+				CompletionIndex: completionIndexOf(pod),
+				PodRetryAttempt: h.podRetryAttemptOf(pod),
+				NodeName:        pod.Spec.NodeName,
 			}
 		}
 	}
 }
 
+// ContainerTypeOf returns the type of the container in the pod.
+func ContainerTypeOf(pod *corev1.Pod, initContainer bool, containerName string) ContainerType {
+	if !initContainer {
+		return ContainerTypeMain
+	}
+	for _, container := range pod.Spec.InitContainers {
+		if container.Name == containerName &&
+			container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			return ContainerTypeSidecar
+		}
+	}
+	return ContainerTypeInit
+}
+
+// completionIndexOf returns the completion index of the pod if the Job is indexed.
+func completionIndexOf(pod *corev1.Pod) *int {
+	value, ok := pod.Annotations[batchv1.JobCompletionIndexAnnotation]
+	if !ok {
+		return nil
+	}
+	index, err := strconv.Atoi(value)
+	if err != nil {
+		slog.Debug("Internal error: invalid completion index", "error", err, "value", value)
+		return nil
+	}
+	return &index
+}
+
+// podRetryAttemptOf returns the number of the failed pods created before the pod,
+// for the same completion index.
+// For a non-indexed Job, the pods running in parallel are not counted.
+func (h *eventHandler) podRetryAttemptOf(pod *corev1.Pod) int {
+	index := completionIndexOf(pod)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var attempt int
+	for _, p := range h.pods {
+		if p.Name == pod.Name || p.Status.Phase != corev1.PodFailed || !ptr.Equal(completionIndexOf(p), index) {
+			continue
+		}
+		if p.CreationTimestamp.Before(&pod.CreationTimestamp) ||
+			(p.CreationTimestamp.Equal(&pod.CreationTimestamp) && p.Name < pod.Name) {
+			attempt++
+		}
+	}
+	return attempt
+}
+
+func (h *eventHandler) recordContainerTerminated(podName string, initContainer bool, oldStatuses, newStatuses []corev1.ContainerStatus) {
+	containerStateChanges := computeContainerStateChanges(oldStatuses, newStatuses)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, change := range containerStateChanges {
+		if change.newState != containerStateTerminated {
+			continue
+		}
+		terminated := change.newStatus.State.Terminated
+		h.terminated = append(h.terminated, ContainerTerminatedState{
+			PodName:       podName,
+			ContainerName: change.newStatus.Name,
+			InitContainer: initContainer,
+			ExitCode:      terminated.ExitCode,
+			Reason:        terminated.Reason,
+			Message:       terminated.Message,
+			FinishedAt:    terminated.FinishedAt.Time,
+		})
+	}
+}
+
 type containerState int
 
 const (
@@ -242,7 +540,16 @@ func getContainerState(containerStatus corev1.ContainerStatus) containerState {
 }
 
 func (h *eventHandler) OnDelete(obj interface{}) {
-	pod := obj.(*corev1.Pod)
+	// If the watch is disconnected, the informer receives a tombstone of the last known state.
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		slog.Warn("Internal error: unexpected object on delete", "object", obj)
+		return
+	}
+	h.recordPod(pod)
 	slog.Info("Pod is deleted",
 		slog.Group("pod", slog.String("namespace", pod.Namespace), slog.String("name", pod.Name)))
 }
@@ -0,0 +1,222 @@
+package pods
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/int128/cronjob-runner/internal/annotation"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+)
+
+func TestEventHandler_OnAdd(t *testing.T) {
+	t.Run("existing pod has running and terminated containers", func(t *testing.T) {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-pod"},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				InitContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "init",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}},
+				}},
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:  "app",
+						State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+					},
+					{
+						Name:  "waiting",
+						State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{}},
+					},
+				},
+			},
+		}
+		containerStartedCh := make(chan ContainerStartedEvent, 10)
+		h := &eventHandler{containerStartedCh: containerStartedCh}
+		h.OnAdd(pod, true)
+		close(containerStartedCh)
+
+		var got []ContainerStartedEvent
+		for e := range containerStartedCh {
+			got = append(got, e)
+		}
+		want := []ContainerStartedEvent{
+			{Namespace: "default", PodName: "example-pod", ContainerName: "init", ContainerType: ContainerTypeInit},
+			{Namespace: "default", PodName: "example-pod", ContainerName: "app", ContainerType: ContainerTypeMain},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("ContainerStartedEvent mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("new pod has no container status", func(t *testing.T) {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-pod"},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		}
+		containerStartedCh := make(chan ContainerStartedEvent, 10)
+		h := &eventHandler{containerStartedCh: containerStartedCh}
+		h.OnAdd(pod, false)
+		close(containerStartedCh)
+
+		var got []ContainerStartedEvent
+		for e := range containerStartedCh {
+			got = append(got, e)
+		}
+		if len(got) != 0 {
+			t.Errorf("wants no event but got %+v", got)
+		}
+	})
+}
+
+func TestContainerTypeOf(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{
+				{Name: "init"},
+				{Name: "sidecar", RestartPolicy: ptr.To(corev1.ContainerRestartPolicyAlways)},
+			},
+			Containers: []corev1.Container{{Name: "app"}},
+		},
+	}
+	got := []ContainerType{
+		ContainerTypeOf(pod, true, "init"),
+		ContainerTypeOf(pod, true, "sidecar"),
+		ContainerTypeOf(pod, false, "app"),
+	}
+	want := []ContainerType{ContainerTypeInit, ContainerTypeSidecar, ContainerTypeMain}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ContainerType mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEventHandler_podRetryAttemptOf(t *testing.T) {
+	newPod := func(name string, created time.Time, phase corev1.PodPhase, annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(created),
+				Annotations:       annotations,
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+	indexed := func(index string) map[string]string {
+		return map[string]string{batchv1.JobCompletionIndexAnnotation: index}
+	}
+	t0 := time.Date(2024, 3, 23, 0, 0, 0, 0, time.UTC)
+
+	t.Run("indexed", func(t *testing.T) {
+		h := &eventHandler{}
+		first0 := newPod("first-0", t0, corev1.PodFailed, indexed("0"))
+		first1 := newPod("first-1", t0, corev1.PodRunning, indexed("1"))
+		second0 := newPod("second-0", t0.Add(time.Minute), corev1.PodRunning, indexed("0"))
+		for _, pod := range []*corev1.Pod{first0, first1, second0} {
+			h.recordPod(pod)
+		}
+		got := []int{h.podRetryAttemptOf(first0), h.podRetryAttemptOf(first1), h.podRetryAttemptOf(second0)}
+		if diff := cmp.Diff([]int{0, 0, 1}, got); diff != "" {
+			t.Errorf("PodRetryAttempt mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(ptr.To(1), completionIndexOf(first1)); diff != "" {
+			t.Errorf("CompletionIndex mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("non-indexed parallel", func(t *testing.T) {
+		h := &eventHandler{}
+		first := newPod("first", t0, corev1.PodSucceeded, nil)
+		second := newPod("second", t0, corev1.PodFailed, nil)
+		third := newPod("third", t0.Add(time.Second), corev1.PodRunning, nil)
+		retry := newPod("retry", t0.Add(time.Minute), corev1.PodRunning, nil)
+		for _, pod := range []*corev1.Pod{first, second, third, retry} {
+			h.recordPod(pod)
+		}
+		got := []int{h.podRetryAttemptOf(first), h.podRetryAttemptOf(second), h.podRetryAttemptOf(third), h.podRetryAttemptOf(retry)}
+		if diff := cmp.Diff([]int{0, 0, 1, 1}, got); diff != "" {
+			t.Errorf("PodRetryAttempt mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+type annotationRecorder []annotation.Annotation
+
+func (r *annotationRecorder) Annotate(a annotation.Annotation) {
+	*r = append(*r, a)
+}
+
+func TestEventHandler_OnUpdate_annotations(t *testing.T) {
+	oldPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-pod"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "app",
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			}},
+		},
+	}
+	newPod := oldPod.DeepCopy()
+	newPod.Status.Phase = corev1.PodFailed
+	newPod.Status.ContainerStatuses[0].State = corev1.ContainerState{
+		Terminated: &corev1.ContainerStateTerminated{ExitCode: 2, Reason: "Error"},
+	}
+	newPod.Status.Conditions = []corev1.PodCondition{{
+		Type:    corev1.DisruptionTarget,
+		Status:  corev1.ConditionTrue,
+		Reason:  "PreemptionByScheduler",
+		Message: "preempted",
+	}}
+	var got annotationRecorder
+	h := &eventHandler{annotator: &got}
+	h.OnUpdate(oldPod, newPod)
+	want := annotationRecorder{
+		{Level: annotation.LevelError, Title: "Pod example-pod is failed"},
+		{Level: annotation.LevelWarning, Title: "Pod example-pod will be terminated due to a disruption", Message: "PreemptionByScheduler: preempted"},
+		{Level: annotation.LevelError, Title: "Container example-pod/app exited with code 2", Message: "Error"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("annotations mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestInformer_IsJobPod(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "example-job-abcde",
+			Labels:    map[string]string{batchv1.JobNameLabel: "example-job"},
+		}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "example-job-other-abcde",
+			Labels:    map[string]string{batchv1.JobNameLabel: "example-job-other"},
+		}},
+	)
+	handler := &eventHandler{}
+	handler.recordPod(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-job-observed"}})
+	i := &informer{
+		handler:   handler,
+		clientset: clientset,
+		namespace: "default",
+		jobName:   "example-job",
+		jobPods:   make(map[string]bool),
+	}
+	for podName, want := range map[string]bool{
+		"example-job-observed":    true,
+		"example-job-abcde":       true,
+		"example-job-other-abcde": false,
+		"example-job-deleted":     false,
+		"other-job-abcde":         false,
+	} {
+		t.Run(podName, func(t *testing.T) {
+			if got := i.IsJobPod(podName); got != want {
+				t.Errorf("IsJobPod wants %v but got %v", want, got)
+			}
+		})
+	}
+}
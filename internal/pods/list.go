@@ -0,0 +1,51 @@
+package pods
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ListTerminatedContainers returns the terminated states of containers in the pod(s) of the job.
+// The informer may not have received the last change of the pods yet,
+// so this is used to confirm the terminated states when the job is finished.
+func ListTerminatedContainers(ctx context.Context, clientset kubernetes.Interface, namespace, jobName string) ([]ContainerTerminatedState, error) {
+	podList, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("batch.kubernetes.io/job-name=%s", jobName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list the pods: %w", err)
+	}
+	var h eventHandler
+	for _, pod := range podList.Items {
+		h.recordContainerTerminated(pod.Name, true, nil, pod.Status.InitContainerStatuses)
+		h.recordContainerTerminated(pod.Name, false, nil, pod.Status.ContainerStatuses)
+	}
+	return h.terminatedContainers(), nil
+}
+
+// MergeTerminatedContainers returns the union of the terminated states.
+// The same termination of a container is included only once.
+func MergeTerminatedContainers(a, b []ContainerTerminatedState) []ContainerTerminatedState {
+	merged := append([]ContainerTerminatedState(nil), a...)
+	for _, state := range b {
+		if !containsTerminatedContainer(merged, state) {
+			merged = append(merged, state)
+		}
+	}
+	return merged
+}
+
+func containsTerminatedContainer(states []ContainerTerminatedState, state ContainerTerminatedState) bool {
+	for _, s := range states {
+		if s.PodName == state.PodName &&
+			s.ContainerName == state.ContainerName &&
+			s.InitContainer == state.InitContainer &&
+			s.FinishedAt.Equal(state.FinishedAt) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package pods
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestListTerminatedContainers(t *testing.T) {
+	t0 := time.Date(2024, 3, 23, 0, 0, 0, 0, time.UTC)
+	clientset := fake.NewClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "example-job-abcde",
+				Labels:    map[string]string{"batch.kubernetes.io/job-name": "example-job"},
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name: "app",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						ExitCode: 137, Reason: "OOMKilled", FinishedAt: metav1.NewTime(t0),
+					}},
+				}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "other-job-abcde",
+				Labels:    map[string]string{"batch.kubernetes.io/job-name": "other-job"},
+			},
+		},
+	)
+	got, err := ListTerminatedContainers(t.Context(), clientset, "default", "example-job")
+	if err != nil {
+		t.Fatalf("ListTerminatedContainers error: %s", err)
+	}
+	want := []ContainerTerminatedState{
+		{PodName: "example-job-abcde", ContainerName: "app", ExitCode: 137, Reason: "OOMKilled", FinishedAt: t0},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("terminated containers mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMergeTerminatedContainers(t *testing.T) {
+	t0 := time.Date(2024, 3, 23, 0, 0, 0, 0, time.UTC)
+	observed := []ContainerTerminatedState{
+		{PodName: "example-pod-1", ContainerName: "app", ExitCode: 1, FinishedAt: t0},
+	}
+	listed := []ContainerTerminatedState{
+		{PodName: "example-pod-1", ContainerName: "app", ExitCode: 1, FinishedAt: t0.In(time.FixedZone("", 3600))},
+		{PodName: "example-pod-2", ContainerName: "app", ExitCode: 137, FinishedAt: t0.Add(time.Minute)},
+	}
+	got := MergeTerminatedContainers(observed, listed)
+	want := []ContainerTerminatedState{
+		{PodName: "example-pod-1", ContainerName: "app", ExitCode: 1, FinishedAt: t0},
+		{PodName: "example-pod-2", ContainerName: "app", ExitCode: 137, FinishedAt: t0.Add(time.Minute)},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("terminated containers mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,45 @@
+package secrets
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/utils/ptr"
+)
+
+// NewFromCronJob creates an ephemeral Secret for the environment variables of the CronJob.
+func NewFromCronJob(cronJob *batchv1.CronJob, secretEnv map[string]string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:    cronJob.Namespace,
+			GenerateName: fmt.Sprintf("%s-", cronJob.Name),
+		},
+		Immutable:  ptr.To(true),
+		StringData: secretEnv,
+	}
+}
+
+// PrintYAML prints the Secret as YAML.
+// All values of the Secret are masked.
+func PrintYAML(secret *corev1.Secret, w io.Writer) {
+	newSecret := secret.DeepCopy()
+	// YAMLPrinter requires GVK
+	newSecret.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+	// Hide the managed fields
+	newSecret.SetManagedFields(nil)
+	for key := range newSecret.Data {
+		newSecret.Data[key] = []byte("***")
+	}
+	for key := range newSecret.StringData {
+		newSecret.StringData[key] = "***"
+	}
+	var printer printers.YAMLPrinter
+	if err := printer.PrintObj(newSecret, w); err != nil {
+		slog.Warn("Internal error: printer.PrintObj", "error", err)
+	}
+}
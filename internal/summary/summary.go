@@ -0,0 +1,212 @@
+package summary
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/int128/cronjob-runner/internal/events"
+	"github.com/int128/cronjob-runner/internal/pods"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Summary represents the outcome of a Job.
+type Summary struct {
+	JobNamespace string
+	JobName      string
+	// Result is the result of the run, such as Succeeded or Failed.
+	Result    string
+	StartTime time.Time
+	EndTime   time.Time
+	Pods      []Pod
+}
+
+// Duration returns the duration of the Job, or zero if unknown.
+func (s Summary) Duration() time.Duration {
+	if s.StartTime.IsZero() || s.EndTime.IsZero() {
+		return 0
+	}
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// Pod represents the outcome of a Pod.
+type Pod struct {
+	Name     string
+	NodeName string
+	Phase    corev1.PodPhase
+	// SchedulingWait is the duration from the creation to scheduled, or zero if not scheduled.
+	SchedulingWait time.Duration
+	Containers     []Container
+}
+
+// Container represents the outcome of a container.
+type Container struct {
+	Name string
+	Type pods.ContainerType
+	// State is one of Waiting, Running or Terminated.
+	State string
+	// ExitCode is nil if the container is not terminated.
+	ExitCode     *int32
+	Reason       string
+	RestartCount int32
+	// ImagePullDuration is zero if the image was not pulled.
+	ImagePullDuration time.Duration
+}
+
+// New returns a summary from the last known state of the Job and Pods.
+// If the Job has not finished, endTime is used as the end.
+func New(job *batchv1.Job, jobPods []*corev1.Pod, imagePulls []events.ImagePull, result string, endTime time.Time) Summary {
+	s := Summary{
+		JobNamespace: job.Namespace,
+		JobName:      job.Name,
+		Result:       result,
+		EndTime:      endTime,
+	}
+	if job.Status.StartTime != nil {
+		s.StartTime = job.Status.StartTime.Time
+	}
+	if job.Status.CompletionTime != nil {
+		s.EndTime = job.Status.CompletionTime.Time
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			s.EndTime = condition.LastTransitionTime.Time
+		}
+	}
+	pullDurations := make(map[[2]string]time.Duration)
+	for _, p := range imagePulls {
+		pullDurations[[2]string{p.PodName, p.ContainerName}] = p.Duration
+	}
+	for _, pod := range jobPods {
+		p := Pod{
+			Name:     pod.Name,
+			NodeName: pod.Spec.NodeName,
+			Phase:    pod.Status.Phase,
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionTrue {
+				p.SchedulingWait = condition.LastTransitionTime.Sub(pod.CreationTimestamp.Time)
+			}
+		}
+		for _, status := range pod.Status.InitContainerStatuses {
+			p.Containers = append(p.Containers, newContainer(pod, true, status, pullDurations))
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			p.Containers = append(p.Containers, newContainer(pod, false, status, pullDurations))
+		}
+		s.Pods = append(s.Pods, p)
+	}
+	return s
+}
+
+func newContainer(pod *corev1.Pod, initContainer bool, status corev1.ContainerStatus, pullDurations map[[2]string]time.Duration) Container {
+	c := Container{
+		Name:              status.Name,
+		Type:              pods.ContainerTypeOf(pod, initContainer, status.Name),
+		RestartCount:      status.RestartCount,
+		ImagePullDuration: pullDurations[[2]string{pod.Name, status.Name}],
+	}
+	switch {
+	case status.State.Terminated != nil:
+		c.State = "Terminated"
+		c.ExitCode = &status.State.Terminated.ExitCode
+		c.Reason = status.State.Terminated.Reason
+	case status.State.Running != nil:
+		c.State = "Running"
+		// Show the reason of the last termination if restarted.
+		if status.LastTerminationState.Terminated != nil {
+			c.Reason = status.LastTerminationState.Terminated.Reason
+		}
+	case status.State.Waiting != nil:
+		c.State = "Waiting"
+		c.Reason = status.State.Waiting.Reason
+	}
+	return c
+}
+
+var columns = []string{"Pod", "Node", "Scheduled in", "Container", "Type", "Image pull", "State", "Exit code", "Restarts", "Reason"}
+
+// rows returns the cells of the table.
+// The pod columns are filled in the first row of each pod.
+func (s Summary) rows() [][]string {
+	var rows [][]string
+	for _, p := range s.Pods {
+		podCells := []string{p.Name, p.NodeName, formatDuration(p.SchedulingWait)}
+		if len(p.Containers) == 0 {
+			rows = append(rows, append(podCells, "", "", "", string(p.Phase), "", "", ""))
+			continue
+		}
+		for i, c := range p.Containers {
+			cells := podCells
+			if i > 0 {
+				cells = []string{"", "", ""}
+			}
+			var exitCode string
+			if c.ExitCode != nil {
+				exitCode = strconv.Itoa(int(*c.ExitCode))
+			}
+			rows = append(rows, append(cells,
+				c.Name,
+				string(c.Type),
+				formatDuration(c.ImagePullDuration),
+				c.State,
+				exitCode,
+				strconv.Itoa(int(c.RestartCount)),
+				c.Reason,
+			))
+		}
+	}
+	return rows
+}
+
+func (s Summary) title() string {
+	title := fmt.Sprintf("Job %s/%s: %s", s.JobNamespace, s.JobName, s.Result)
+	if d := s.Duration(); d > 0 {
+		title += fmt.Sprintf(" in %s", d.Round(time.Second))
+	}
+	return title
+}
+
+// WriteTable writes the summary as a plain text table.
+func (s Summary) WriteTable(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, s.title()); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, row := range s.rows() {
+		_, _ = fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// WriteMarkdown writes the summary as Markdown.
+func (s Summary) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "### %s\n\n", s.title())
+	_, _ = fmt.Fprintf(&b, "| %s |\n", strings.Join(columns, " | "))
+	_, _ = fmt.Fprintf(&b, "|%s\n", strings.Repeat(" --- |", len(columns)))
+	for _, row := range s.rows() {
+		for i := range row {
+			row[i] = strings.ReplaceAll(row[i], "|", `\|`)
+		}
+		_, _ = fmt.Fprintf(&b, "| %s |\n", strings.Join(row, " | "))
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatDuration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
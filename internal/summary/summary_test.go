@@ -0,0 +1,88 @@
+package summary
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/int128/cronjob-runner/internal/events"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newExampleSummary() Summary {
+	t0 := time.Date(2024, 3, 23, 5, 29, 0, 0, time.UTC)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"},
+		Status: batchv1.JobStatus{
+			StartTime: &metav1.Time{Time: t0},
+			Conditions: []batchv1.JobCondition{{
+				Type:               batchv1.JobFailed,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.Time{Time: t0.Add(42 * time.Second)},
+			}},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-abcde", CreationTimestamp: metav1.Time{Time: t0}},
+		Spec: corev1.PodSpec{
+			NodeName:       "node-1",
+			InitContainers: []corev1.Container{{Name: "init"}},
+			Containers:     []corev1.Container{{Name: "app"}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodFailed,
+			Conditions: []corev1.PodCondition{{
+				Type:               corev1.PodScheduled,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.Time{Time: t0.Add(1500 * time.Millisecond)},
+			}},
+			InitContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "init",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0, Reason: "Completed"}},
+			}},
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:         "app",
+				RestartCount: 1,
+				State:        corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 3, Reason: "Error"}},
+			}},
+		},
+	}
+	imagePulls := []events.ImagePull{{PodName: "example-abcde", ContainerName: "app", Duration: 2345 * time.Millisecond}}
+	return New(job, []*corev1.Pod{pod}, imagePulls, "Failed", t0.Add(time.Hour))
+}
+
+func TestSummary_WriteTable(t *testing.T) {
+	var b strings.Builder
+	if err := newExampleSummary().WriteTable(&b); err != nil {
+		t.Fatalf("WriteTable error: %s", err)
+	}
+	want := `Job default/example: Failed in 42s
+POD            NODE    SCHEDULED IN  CONTAINER  TYPE  IMAGE PULL  STATE       EXIT CODE  RESTARTS  REASON
+example-abcde  node-1  1.5s          init       init              Terminated  0          0         Completed
+                                     app        main  2.3s        Terminated  3          1         Error
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("table mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSummary_WriteMarkdown(t *testing.T) {
+	var b strings.Builder
+	if err := newExampleSummary().WriteMarkdown(&b); err != nil {
+		t.Fatalf("WriteMarkdown error: %s", err)
+	}
+	want := `### Job default/example: Failed in 42s
+
+| Pod | Node | Scheduled in | Container | Type | Image pull | State | Exit code | Restarts | Reason |
+| --- | --- | --- | --- | --- | --- | --- | --- | --- | --- |
+| example-abcde | node-1 | 1.5s | init | init |  | Terminated | 0 | 0 | Completed |
+|  |  |  | app | main | 2.3s | Terminated | 3 | 1 | Error |
+
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("markdown mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/int128/cronjob-runner/runner"
+	"github.com/spf13/pflag"
+)
+
+// logOptions represents the options of the status logs and container logs.
+type logOptions struct {
+	format          string
+	level           string
+	containerFormat string
+	dir             string
+	dirGzip         bool
+	ci              string
+	neutralize      bool
+}
+
+func (o *logOptions) addFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.format, "log-format", "text",
+		"Format of the status logs. Must be one of text, json or logfmt. Container logs are not affected")
+	flags.StringVar(&o.level, "log-level", "info",
+		"Level of the status logs. Must be one of debug, info, warn or error")
+	flags.StringVar(&o.containerFormat, "container-log-format", "plain",
+		"Format of the container logs. Must be one of plain, prefixed, color, json or none")
+	flags.StringVar(&o.dir, "log-dir", "",
+		"Directory to write the container logs into <dir>/<pod>/<container>.log, in addition to stdout")
+	flags.BoolVar(&o.dirGzip, "log-dir-gzip", false,
+		"Compress the files in --log-dir with gzip")
+	flags.StringVar(&o.ci, "ci", "auto",
+		"CI system to write the sections, annotations and summary. Must be one of auto, none, github, gitlab or buildkite")
+	flags.BoolVar(&o.neutralize, "neutralize-workflow-commands", runner.DetectCIProvider() == runner.CIProviderGitHubActions,
+		"Prevent the container logs from being interpreted as workflow commands of GitHub Actions (default to true on GitHub Actions)")
+}
+
+// setup configures the default logger.
+func (o *logOptions) setup() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(o.level)); err != nil {
+		return fmt.Errorf("invalid --log-level: %w", err)
+	}
+	handlerOptions := &slog.HandlerOptions{Level: level}
+	switch o.format {
+	case "text":
+		// The default handler writes to the standard logger.
+		slog.SetLogLoggerLevel(level)
+	case "logfmt":
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, handlerOptions)))
+	case "json":
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, handlerOptions)))
+	default:
+		return fmt.Errorf("--log-format must be one of text, json or logfmt")
+	}
+	return nil
+}
+
+// containerLogger returns the ContainerLogger for --container-log-format and --log-dir.
+func (o *logOptions) containerLogger() (runner.ContainerLogger, error) {
+	stdoutLogger, err := o.stdoutContainerLogger()
+	if err != nil {
+		return nil, err
+	}
+	if o.dir == "" {
+		return stdoutLogger, nil
+	}
+	return runner.NewMultiContainerLogger(stdoutLogger, runner.NewFileContainerLogger(o.dir, o.dirGzip)), nil
+}
+
+func (o *logOptions) stdoutContainerLogger() (runner.ContainerLogger, error) {
+	switch o.containerFormat {
+	case "plain":
+		return runner.NewPlainContainerLogger(os.Stdout), nil
+	case "prefixed":
+		return runner.NewPrefixedContainerLogger(os.Stdout), nil
+	case "color":
+		return runner.NewColorContainerLogger(os.Stdout), nil
+	case "json":
+		return runner.NewJSONContainerLogger(os.Stdout), nil
+	case "none":
+		return runner.NewDiscardContainerLogger(), nil
+	}
+	return nil, fmt.Errorf("--container-log-format must be one of plain, prefixed, color, json or none")
+}
+
+// ciProvider returns the CI system for --ci.
+func (o *logOptions) ciProvider() (runner.CIProvider, error) {
+	switch o.ci {
+	case "auto":
+		return runner.DetectCIProvider(), nil
+	case "none":
+		return runner.CIProviderNone, nil
+	case "github":
+		return runner.CIProviderGitHubActions, nil
+	case "gitlab":
+		return runner.CIProviderGitLab, nil
+	case "buildkite":
+		return runner.CIProviderBuildkite, nil
+	}
+	return "", fmt.Errorf("--ci must be one of auto, none, github, gitlab or buildkite")
+}
@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/int128/cronjob-runner/runner"
 	"github.com/spf13/pflag"
@@ -13,6 +17,18 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
+// Exit codes of the command.
+// See the README for details.
+const (
+	exitCodeError          = 1
+	exitCodeUsage          = 2
+	exitCodeJobRejected    = 65
+	exitCodeNotFound       = 66
+	exitCodeInfrastructure = 69
+	exitCodeTimeout        = 124
+	exitCodeCanceled       = 130
+)
+
 type options struct {
 	runner.RunCronJobOptions
 	Namespace   string
@@ -20,28 +36,109 @@ type options struct {
 }
 
 func run(clientset kubernetes.Interface, opts options) error {
-	ctx := context.Background()
-	ctx, stopNotifyCtx := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
-	defer stopNotifyCtx()
+	ctx, stop := notifyContext()
+	defer stop()
 
 	return runner.RunJobFromCronJob(ctx, clientset, opts.Namespace, opts.CronJobName, opts.RunCronJobOptions)
 }
 
+// notifyContext returns a context which is canceled on the first signal.
+// On the second signal, it exits immediately.
+func notifyContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		// On the first signal, stop gracefully.
+		sig := <-signalCh
+		cancel(fmt.Errorf("received %s", sig))
+		// On the second signal, exit immediately.
+		sig = <-signalCh
+		slog.Warn("Received the signal again, exiting immediately", "signal", sig)
+		os.Exit(exitCodeCanceled)
+	}()
+	return ctx, func() {
+		signal.Stop(signalCh)
+		cancel(nil)
+	}
+}
+
 func main() {
 	log.SetFlags(log.Lmicroseconds | log.Lshortfile)
 
+	if len(os.Args) > 1 && (os.Args[1] == "attach" || os.Args[1] == "wait") {
+		attachMain(os.Args[2:])
+		return
+	}
+
 	var opts options
 	var secretEnvKeys []string
+	var onCancel, dryRun string
+	var logOpts logOptions
+	var maskSecretEnv bool
 	pflag.StringVar(&opts.CronJobName, "cronjob-name", "", "Name of CronJob")
 	pflag.StringToStringVar(&opts.Env, "env", nil,
 		"Environment variables to set into the all containers, in the form of KEY=VALUE")
 	pflag.StringArrayVar(&secretEnvKeys, "secret-env", nil,
 		"Environment variable keys of secrets to set into the all containers")
+	pflag.BoolVar(&maskSecretEnv, "mask-secret-env", true,
+		"Replace the values of --secret-env with *** in the container logs")
+	pflag.StringSliceVar(&opts.EnvContainerNames, "env-container", nil,
+		"Names of containers to set --env and --secret-env. Init containers are also available (default to all containers)")
+	pflag.StringArrayVar(&opts.Command, "command", nil,
+		"Override the command of the container. Repeat for each element, e.g., --command sh --command -c")
+	pflag.StringArrayVar(&opts.Args, "args", nil,
+		"Override the args of the container. Repeat for each element")
+	pflag.StringVar(&opts.ContainerName, "container", "",
+		"Name of container to override --command and --args (default to the first container)")
+	pflag.StringToStringVar(&opts.Images, "image", nil,
+		"Override the image of the container, in the form of CONTAINER=IMAGE")
+	pflag.DurationVar(&opts.Timeout, "timeout", 0,
+		"Maximum duration of the Job. On expiry, the Job is deleted (default to no timeout)")
+	pflag.BoolVar(&opts.ShowNormalEvents, "show-normal-events", false,
+		"Show the Normal events of the Job and Pod(s) in addition to the Warning events")
+	pflag.StringVar(&onCancel, "on-cancel", "keep",
+		"Must be one of keep, delete or suspend. Clean up the Job when this command is interrupted")
+	pflag.StringVar(&dryRun, "dry-run", "none",
+		"Must be one of none, client or server. If client, print the Job without sending it. If server, submit the Job in dry-run mode")
+	pflag.DurationVar(&opts.ContainerLogMaxRetryDuration, "container-log-max-retry-duration", 5*time.Minute,
+		"Maximum duration to retry tailing a container log on errors")
+	logOpts.addFlags(pflag.CommandLine)
 	kubernetesFlags := genericclioptions.NewConfigFlags(false)
 	kubernetesFlags.AddFlags(pflag.CommandLine)
 	pflag.Parse()
+	if err := logOpts.setup(); err != nil {
+		// The status logger is not configured yet.
+		log.Printf("%s", err)
+		os.Exit(exitCodeUsage)
+	}
+	containerLogger, err := logOpts.containerLogger()
+	if err != nil {
+		slog.Error("Invalid flags", "error", err)
+		os.Exit(exitCodeUsage)
+	}
+	opts.ContainerLogger = containerLogger
+	opts.CI, err = logOpts.ciProvider()
+	if err != nil {
+		slog.Error("Invalid flags", "error", err)
+		os.Exit(exitCodeUsage)
+	}
+	opts.NeutralizeWorkflowCommands = logOpts.neutralize
 	if opts.CronJobName == "" {
-		log.Fatalf("You need to set --cronjob-name")
+		slog.Error("You need to set --cronjob-name")
+		os.Exit(exitCodeUsage)
+	}
+	opts.OnCancel = parseCancelPolicy(onCancel)
+	switch dryRun {
+	case "none":
+		opts.DryRun = runner.DryRunNone
+	case "client":
+		opts.DryRun = runner.DryRunClient
+	case "server":
+		opts.DryRun = runner.DryRunServer
+	default:
+		slog.Error("--dry-run must be one of none, client or server")
+		os.Exit(exitCodeUsage)
 	}
 	if len(secretEnvKeys) > 0 {
 		opts.SecretEnv = make(map[string]string, len(secretEnvKeys))
@@ -49,21 +146,73 @@ func main() {
 			opts.SecretEnv[key] = os.Getenv(key)
 		}
 	}
+	opts.DisableSecretEnvMasking = !maskSecretEnv
+
+	var clientset kubernetes.Interface
+	clientset, opts.Namespace = newClientset(kubernetesFlags)
 
+	if err := run(clientset, opts); err != nil {
+		slog.Error("Failed to run the Job", "error", err)
+		var infrastructureError runner.InfrastructureError
+		if errors.As(err, &infrastructureError) && infrastructureError.JobName != "" {
+			slog.Error("The Job may be still running. Do not retry, but attach to the Job",
+				"command", fmt.Sprintf("cronjob-runner attach --namespace %s --job-name %s",
+					infrastructureError.JobNamespace, infrastructureError.JobName))
+		}
+		os.Exit(exitCodeOf(err))
+	}
+}
+
+func parseCancelPolicy(onCancel string) runner.CancelPolicy {
+	switch runner.CancelPolicy(onCancel) {
+	case runner.CancelPolicyKeep, runner.CancelPolicyDelete, runner.CancelPolicySuspend:
+		return runner.CancelPolicy(onCancel)
+	}
+	slog.Error("--on-cancel must be one of keep, delete or suspend")
+	os.Exit(exitCodeUsage)
+	return ""
+}
+
+// newClientset returns a Kubernetes client and the namespace.
+func newClientset(kubernetesFlags *genericclioptions.ConfigFlags) (kubernetes.Interface, string) {
 	restCfg, err := kubernetesFlags.ToRESTConfig()
 	if err != nil {
-		log.Fatalf("Failed to load the Kubernetes config: %s", err)
+		slog.Error("Failed to load the Kubernetes config", "error", err)
+		os.Exit(exitCodeError)
 	}
 	clientset, err := kubernetes.NewForConfig(restCfg)
 	if err != nil {
-		log.Fatalf("Failed to create a Kubernetes client: %s", err)
+		slog.Error("Failed to create a Kubernetes client", "error", err)
+		os.Exit(exitCodeError)
 	}
-	opts.Namespace, _, err = kubernetesFlags.ToRawKubeConfigLoader().Namespace()
+	namespace, _, err := kubernetesFlags.ToRawKubeConfigLoader().Namespace()
 	if err != nil {
-		log.Fatalf("Failed to determine the namespace: %s", err)
+		slog.Error("Failed to determine the namespace", "error", err)
+		os.Exit(exitCodeError)
 	}
+	return clientset, namespace
+}
 
-	if err := run(clientset, opts); err != nil {
-		log.Fatalf("Error: %s", err)
+func exitCodeOf(err error) int {
+	var jobFailedError runner.JobFailedError
+	if errors.As(err, &jobFailedError) {
+		// Propagate the exit code of the failed container.
+		return jobFailedError.ExitCode()
+	}
+	if errors.As(err, &runner.JobDeadlineExceededError{}) {
+		return exitCodeTimeout
+	}
+	if errors.As(err, &runner.CanceledError{}) {
+		return exitCodeCanceled
+	}
+	if errors.As(err, &runner.CronJobNotFoundError{}) || errors.As(err, &runner.JobNotFoundError{}) {
+		return exitCodeNotFound
+	}
+	if errors.As(err, &runner.JobCreationError{}) {
+		return exitCodeJobRejected
+	}
+	if errors.As(err, &runner.InfrastructureError{}) {
+		return exitCodeInfrastructure
 	}
+	return exitCodeError
 }
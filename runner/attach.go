@@ -0,0 +1,36 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// AttachJob waits for the existing Job, such as a Job created by the previous run or kubectl.
+//
+// It follows the Job in the same way as RunJobFromCronJob.
+// See WaitForJob() for details.
+//
+// If the job is succeeded, it returns nil.
+// If the Job does not exist, it returns JobNotFoundError.
+// Otherwise, it returns an error of WaitForJob().
+func AttachJob(ctx context.Context, clientset kubernetes.Interface, namespace, jobName string, opts WaitForJobOptions) error {
+	job, err := clientset.BatchV1().Jobs(namespace).Get(ctx, jobName, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return JobNotFoundError{JobNamespace: namespace, JobName: jobName}
+	}
+	if err != nil {
+		return fmt.Errorf("get the Job: %w", wrapAPIError(ctx, err))
+	}
+	slog.Info("Found the Job",
+		slog.Group("job", slog.String("namespace", job.Namespace), slog.String("name", job.Name)))
+
+	if err := WaitForJob(ctx, clientset, job, opts); err != nil {
+		return fmt.Errorf("wait for the Job: %w", err)
+	}
+	return nil
+}
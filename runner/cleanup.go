@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+// cleanupTimeout is the maximum duration to clean up the Job after the context is canceled
+// or the timeout is exceeded.
+const cleanupTimeout = 5 * time.Minute
+
+// cancelJob cleans up the Job in the policy after the context is canceled,
+// and returns CanceledError.
+func cancelJob(ctx context.Context, clientset kubernetes.Interface, job *batchv1.Job, policy CancelPolicy) error {
+	slog.Info("Shutting down")
+	if err := cleanupJob(clientset, job, policy); err != nil {
+		slog.Warn("Failed to clean up the Job", "error", err)
+	}
+	return CanceledError{Cause: context.Cause(ctx)}
+}
+
+// cleanupJob cleans up the Job in the policy.
+// It is called after the context is canceled, so it uses a new context.
+func cleanupJob(clientset kubernetes.Interface, job *batchv1.Job, policy CancelPolicy) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	switch policy {
+	case CancelPolicyDelete:
+		if err := deleteJob(ctx, clientset, job); err != nil {
+			return err
+		}
+		if err := waitForJobDeleted(ctx, clientset, job); err != nil {
+			return err
+		}
+		return nil
+	case CancelPolicySuspend:
+		return suspendJob(ctx, clientset, job)
+	default:
+		slog.Info("Keeping the Job running in the cluster",
+			slog.Group("job", slog.String("namespace", job.Namespace), slog.String("name", job.Name)))
+		return nil
+	}
+}
+
+// deleteJobOnTimeout deletes the Job after WaitForJobOptions.Timeout is exceeded.
+// It uses a new context in case the context is canceled at the same time.
+func deleteJobOnTimeout(clientset kubernetes.Interface, job *batchv1.Job) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	return deleteJob(ctx, clientset, job)
+}
+
+// deleteJob deletes the Job and its Pods in foreground.
+func deleteJob(ctx context.Context, clientset kubernetes.Interface, job *batchv1.Job) error {
+	jobAttr := slog.Group("job", slog.String("namespace", job.Namespace), slog.String("name", job.Name))
+	if err := clientset.BatchV1().Jobs(job.Namespace).Delete(ctx, job.Name, metav1.DeleteOptions{
+		PropagationPolicy: ptr.To(metav1.DeletePropagationForeground),
+	}); err != nil {
+		return fmt.Errorf("delete the Job: %w", err)
+	}
+	slog.Info("Deleting the Job", jobAttr)
+	return nil
+}
+
+// waitForJobDeleted waits until the Job is removed.
+// In the foreground deletion, the Job is removed after all Pods are terminated.
+func waitForJobDeleted(ctx context.Context, clientset kubernetes.Interface, job *batchv1.Job) error {
+	jobAttr := slog.Group("job", slog.String("namespace", job.Namespace), slog.String("name", job.Name))
+	if err := wait.PollUntilContextCancel(ctx, time.Second, true, func(ctx context.Context) (bool, error) {
+		_, err := clientset.BatchV1().Jobs(job.Namespace).Get(ctx, job.Name, metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			slog.Warn("Retrying to get the Job", jobAttr, "error", err)
+		}
+		return false, nil
+	}); err != nil {
+		return fmt.Errorf("wait for the Job to be deleted: %w", err)
+	}
+	slog.Info("Deleted the Job and its Pods", jobAttr)
+	return nil
+}
+
+// suspendJob suspends the Job.
+// The Job controller terminates the running Pods.
+func suspendJob(ctx context.Context, clientset kubernetes.Interface, job *batchv1.Job) error {
+	if _, err := clientset.BatchV1().Jobs(job.Namespace).Patch(ctx, job.Name,
+		types.MergePatchType, []byte(`{"spec":{"suspend":true}}`), metav1.PatchOptions{},
+	); err != nil {
+		return fmt.Errorf("suspend the Job: %w", err)
+	}
+	slog.Info("Suspended the Job",
+		slog.Group("job", slog.String("namespace", job.Namespace), slog.String("name", job.Name)))
+	return nil
+}
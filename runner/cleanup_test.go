@@ -0,0 +1,129 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	batchv1 "k8s.io/api/batch/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+)
+
+func TestCleanupJob(t *testing.T) {
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-job"}}
+
+	t.Run("delete", func(t *testing.T) {
+		clientset := fake.NewClientset(job)
+		if err := cleanupJob(clientset, job, CancelPolicyDelete); err != nil {
+			t.Fatalf("cleanupJob error: %s", err)
+		}
+		var deleteAction k8stesting.DeleteAction
+		for _, action := range clientset.Actions() {
+			if a, ok := action.(k8stesting.DeleteAction); ok {
+				deleteAction = a
+			}
+		}
+		if deleteAction == nil {
+			t.Fatalf("Job must be deleted")
+		}
+		if deleteAction.GetName() != "example-job" {
+			t.Errorf("name wants example-job but got %s", deleteAction.GetName())
+		}
+		want := ptr.To(metav1.DeletePropagationForeground)
+		if diff := cmp.Diff(want, deleteAction.GetDeleteOptions().PropagationPolicy); diff != "" {
+			t.Errorf("PropagationPolicy mismatch (-want +got):\n%s", diff)
+		}
+		_, err := clientset.BatchV1().Jobs("default").Get(t.Context(), "example-job", metav1.GetOptions{})
+		if !kerrors.IsNotFound(err) {
+			t.Errorf("Job must be deleted but got %v", err)
+		}
+	})
+
+	t.Run("suspend", func(t *testing.T) {
+		clientset := fake.NewClientset(job)
+		if err := cleanupJob(clientset, job, CancelPolicySuspend); err != nil {
+			t.Fatalf("cleanupJob error: %s", err)
+		}
+		var patchAction k8stesting.PatchAction
+		for _, action := range clientset.Actions() {
+			if a, ok := action.(k8stesting.PatchAction); ok {
+				patchAction = a
+			}
+		}
+		if patchAction == nil {
+			t.Fatalf("Job must be patched")
+		}
+		if patchAction.GetPatchType() != types.MergePatchType {
+			t.Errorf("patch type wants %s but got %s", types.MergePatchType, patchAction.GetPatchType())
+		}
+		if diff := cmp.Diff(`{"spec":{"suspend":true}}`, string(patchAction.GetPatch())); diff != "" {
+			t.Errorf("patch mismatch (-want +got):\n%s", diff)
+		}
+		got, err := clientset.BatchV1().Jobs("default").Get(t.Context(), "example-job", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+		if !ptr.Deref(got.Spec.Suspend, false) {
+			t.Errorf("Job must be suspended")
+		}
+	})
+
+	t.Run("keep", func(t *testing.T) {
+		clientset := fake.NewClientset(job)
+		if err := cleanupJob(clientset, job, CancelPolicyKeep); err != nil {
+			t.Fatalf("cleanupJob error: %s", err)
+		}
+		if len(clientset.Actions()) != 0 {
+			t.Errorf("wants no action but got %+v", clientset.Actions())
+		}
+	})
+}
+
+func TestWaitForJob_canceled(t *testing.T) {
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-job"}}
+	clientset := fake.NewClientset(job)
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	err := WaitForJob(ctx, clientset, job, WaitForJobOptions{OnCancel: CancelPolicyDelete, Timeout: 10 * time.Second})
+	if !errors.As(err, &CanceledError{}) {
+		t.Errorf("WaitForJob wants CanceledError but got %#v", err)
+	}
+	_, err = clientset.BatchV1().Jobs("default").Get(t.Context(), "example-job", metav1.GetOptions{})
+	if !kerrors.IsNotFound(err) {
+		t.Errorf("Job must be deleted but got %v", err)
+	}
+}
+
+func TestRunJobFromCronJob_canceledAfterJobCreated(t *testing.T) {
+	cronJob := newExampleCronJob()
+	clientset := fake.NewClientset(cronJob)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	// Cancel the context on applying the owner reference to the Secret, i.e., after the Job is created.
+	clientset.PrependReactor("patch", "secrets", func(k8stesting.Action) (bool, runtime.Object, error) {
+		cancel()
+		return true, nil, context.Canceled
+	})
+	err := RunJobFromCronJob(ctx, clientset, "default", "example", RunCronJobOptions{
+		SecretEnv: map[string]string{"PASSWORD": "s3cr3t"},
+		OnCancel:  CancelPolicyDelete,
+	})
+	if !errors.As(err, &CanceledError{}) {
+		t.Errorf("RunJobFromCronJob wants CanceledError but got %#v", err)
+	}
+	jobList, err := clientset.BatchV1().Jobs("default").List(t.Context(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("List error: %s", err)
+	}
+	if len(jobList.Items) != 0 {
+		t.Errorf("Job must be deleted but got %d job(s)", len(jobList.Items))
+	}
+}
@@ -0,0 +1,151 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// structuredContainerLogger is an optional interface of the built-in ContainerLogger.
+// If structured returns true, the logger does not write the lines of text to stdout,
+// such as NDJSON or nothing.
+// The CI sections and workflow commands are not written to stdout for it,
+// otherwise they break the structured output.
+type structuredContainerLogger interface {
+	structured() bool
+}
+
+// isStructuredContainerLogger returns true if the ContainerLogger writes a structured output.
+// It returns false for a custom ContainerLogger.
+func isStructuredContainerLogger(l ContainerLogger) bool {
+	if s, ok := l.(structuredContainerLogger); ok {
+		return s.structured()
+	}
+	return false
+}
+
+// NewPlainContainerLogger returns a ContainerLogger which writes the message only.
+func NewPlainContainerLogger(w io.Writer) ContainerLogger {
+	return &plainContainerLogger{w: w}
+}
+
+type plainContainerLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *plainContainerLogger) Handle(record ContainerLogRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = fmt.Fprintln(l.w, record.Message)
+}
+
+// NewPrefixedContainerLogger returns a ContainerLogger which writes the message
+// with the prefix of pod and container name, such as "[pod/container] message".
+func NewPrefixedContainerLogger(w io.Writer) ContainerLogger {
+	return &prefixedContainerLogger{w: w}
+}
+
+type prefixedContainerLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *prefixedContainerLogger) Handle(record ContainerLogRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = fmt.Fprintf(l.w, "[%s/%s] %s\n", record.PodName, record.ContainerName, record.Message)
+}
+
+// containerLogColors is a list of the ANSI escape sequences for the prefix.
+// Red is excluded to avoid confusion with errors.
+var containerLogColors = []string{
+	"\x1b[32m", // green
+	"\x1b[33m", // yellow
+	"\x1b[34m", // blue
+	"\x1b[35m", // magenta
+	"\x1b[36m", // cyan
+	"\x1b[92m", // bright green
+	"\x1b[93m", // bright yellow
+	"\x1b[94m", // bright blue
+	"\x1b[95m", // bright magenta
+	"\x1b[96m", // bright cyan
+}
+
+const containerLogColorReset = "\x1b[0m"
+
+// NewColorContainerLogger returns a ContainerLogger which writes the message
+// with the colored prefix of pod and container name.
+// The color is determined by the pod and container name,
+// so that it is stable across runs.
+func NewColorContainerLogger(w io.Writer) ContainerLogger {
+	return &colorContainerLogger{w: w}
+}
+
+type colorContainerLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *colorContainerLogger) Handle(record ContainerLogRecord) {
+	color := containerLogColorOf(record.PodName, record.ContainerName)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = fmt.Fprintf(l.w, "%s[%s/%s]%s %s\n",
+		color, record.PodName, record.ContainerName, containerLogColorReset, record.Message)
+}
+
+func containerLogColorOf(podName, containerName string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(podName + "/" + containerName))
+	return containerLogColors[h.Sum32()%uint32(len(containerLogColors))]
+}
+
+// NewJSONContainerLogger returns a ContainerLogger which writes a JSON object per line (NDJSON).
+// The legacy workflow command "##[" of GitHub Actions is escaped as "#\u0023[",
+// so that a line is never interpreted as a workflow command.
+func NewJSONContainerLogger(w io.Writer) ContainerLogger {
+	return &jsonContainerLogger{w: w}
+}
+
+type jsonContainerLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+type jsonContainerLogRecord struct {
+	Timestamp string `json:"timestamp,omitempty"`
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Message   string `json:"message"`
+	Partial   bool   `json:"partial,omitempty"`
+}
+
+func (l *jsonContainerLogger) structured() bool { return true }
+
+func (l *jsonContainerLogger) Handle(record ContainerLogRecord) {
+	b, err := json.Marshal(jsonContainerLogRecord{
+		Timestamp: record.RawTimestamp,
+		Namespace: record.Namespace,
+		Pod:       record.PodName,
+		Container: record.ContainerName,
+		Message:   record.Message,
+		Partial:   record.Partial,
+	})
+	if err != nil {
+		slog.Error("Failed to write the container log", "error", err)
+		return
+	}
+	b = bytes.ReplaceAll(b, []byte("##["), []byte(`#\u0023[`))
+	b = append(b, '\n')
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.w.Write(b); err != nil {
+		slog.Error("Failed to write the container log", "error", err)
+	}
+}
@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestContainerLoggers(t *testing.T) {
+	record := ContainerLogRecord{
+		RawTimestamp:  "2024-03-23T05:29:26.862529Z",
+		Namespace:     "default",
+		PodName:       "example-pod",
+		ContainerName: "example",
+		Message:       "hello",
+	}
+	for name, tc := range map[string]struct {
+		newLogger func(b *bytes.Buffer) ContainerLogger
+		want      string
+	}{
+		"plain": {
+			newLogger: func(b *bytes.Buffer) ContainerLogger { return NewPlainContainerLogger(b) },
+			want:      "hello\n",
+		},
+		"prefixed": {
+			newLogger: func(b *bytes.Buffer) ContainerLogger { return NewPrefixedContainerLogger(b) },
+			want:      "[example-pod/example] hello\n",
+		},
+		"color": {
+			newLogger: func(b *bytes.Buffer) ContainerLogger { return NewColorContainerLogger(b) },
+			want: containerLogColorOf("example-pod", "example") +
+				"[example-pod/example]" + containerLogColorReset + " hello\n",
+		},
+		"json": {
+			newLogger: func(b *bytes.Buffer) ContainerLogger { return NewJSONContainerLogger(b) },
+			want: `{"timestamp":"2024-03-23T05:29:26.862529Z","namespace":"default",` +
+				`"pod":"example-pod","container":"example","message":"hello"}` + "\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var b bytes.Buffer
+			tc.newLogger(&b).Handle(record)
+			if diff := cmp.Diff(tc.want, b.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestContainerLogColorOf(t *testing.T) {
+	if containerLogColorOf("pod", "a") != containerLogColorOf("pod", "a") {
+		t.Errorf("color must be stable for the same container")
+	}
+}
+
+func TestJSONContainerLogger_workflowCommand(t *testing.T) {
+	var b bytes.Buffer
+	NewJSONContainerLogger(&b).Handle(ContainerLogRecord{Message: "foo ##[set-env name=A]b"})
+	want := `{"namespace":"","pod":"","container":"","message":"foo #\u0023[set-env name=A]b"}` + "\n"
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestIsStructuredContainerLogger(t *testing.T) {
+	var b bytes.Buffer
+	for name, tc := range map[string]struct {
+		logger ContainerLogger
+		want   bool
+	}{
+		"plain":   {logger: NewPlainContainerLogger(&b), want: false},
+		"json":    {logger: NewJSONContainerLogger(&b), want: true},
+		"discard": {logger: NewDiscardContainerLogger(), want: true},
+		"json and file": {
+			logger: NewMultiContainerLogger(NewJSONContainerLogger(&b), NewFileContainerLogger(t.TempDir(), false)),
+			want:   true,
+		},
+		"plain and file": {
+			logger: NewMultiContainerLogger(NewPlainContainerLogger(&b), NewFileContainerLogger(t.TempDir(), false)),
+			want:   false,
+		},
+		"redacting json": {logger: NewRedactingContainerLogger(NewJSONContainerLogger(&b), []string{"s3cr3t"}), want: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := isStructuredContainerLogger(tc.logger); got != tc.want {
+				t.Errorf("isStructuredContainerLogger wants %v but got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/int128/cronjob-runner/internal/jobs"
+	"github.com/int128/cronjob-runner/internal/secrets"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// dryRunClient prints the Job and Secret to w without sending them to the API server.
+func dryRunClient(cronJob *batchv1.CronJob, opts RunCronJobOptions, w io.Writer) error {
+	var secretRef *corev1.LocalObjectReference
+	if len(opts.SecretEnv) > 0 {
+		secret := secrets.NewFromCronJob(cronJob, opts.SecretEnv)
+		// The actual name is generated by the API server.
+		secretRef = &corev1.LocalObjectReference{Name: secret.GenerateName}
+		secrets.PrintYAML(secret, w)
+		_, _ = fmt.Fprintln(w, "---")
+	}
+	job, err := jobs.NewFromCronJob(cronJob, newJobOptions(opts, secretRef))
+	if err != nil {
+		return fmt.Errorf("could not create a Job from the CronJob: %w", err)
+	}
+	jobs.PrintYAML(job, w)
+	slog.Info("Dry-run: the Job is not created")
+	return nil
+}
+
+// dryRunServer submits the Job and Secret to the API server in dry-run mode, and prints them to w.
+func dryRunServer(ctx context.Context, clientset kubernetes.Interface, cronJob *batchv1.CronJob, opts RunCronJobOptions, w io.Writer) error {
+	dryRunCreateOptions := metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}}
+	var secretRef *corev1.LocalObjectReference
+	if len(opts.SecretEnv) > 0 {
+		secret, err := clientset.CoreV1().Secrets(cronJob.Namespace).Create(ctx,
+			secrets.NewFromCronJob(cronJob, opts.SecretEnv),
+			dryRunCreateOptions,
+		)
+		if err != nil {
+			return fmt.Errorf("create a Secret (dry-run): %w", wrapCreationError(ctx, err))
+		}
+		secretRef = &corev1.LocalObjectReference{Name: secret.Name}
+		secrets.PrintYAML(secret, w)
+		_, _ = fmt.Fprintln(w, "---")
+	}
+	jobToCreate, err := jobs.NewFromCronJob(cronJob, newJobOptions(opts, secretRef))
+	if err != nil {
+		return fmt.Errorf("could not create a Job from the CronJob: %w", err)
+	}
+	job, err := clientset.BatchV1().Jobs(cronJob.Namespace).Create(ctx, jobToCreate, dryRunCreateOptions)
+	if err != nil {
+		return fmt.Errorf("create a Job (dry-run): %w", wrapCreationError(ctx, err))
+	}
+	jobs.PrintYAML(job, w)
+	slog.Info("Dry-run: the Job is accepted by the API server but not created",
+		slog.Group("job", slog.String("namespace", job.Namespace), slog.String("name", job.Name)))
+	return nil
+}
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newExampleCronJob() *batchv1.CronJob {
+	return &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example"},
+		Spec: batchv1.CronJobSpec{
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDryRunClient(t *testing.T) {
+	cronJob := newExampleCronJob()
+	clientset := fake.NewClientset(cronJob)
+	var b bytes.Buffer
+	opts := RunCronJobOptions{SecretEnv: map[string]string{"PASSWORD": "s3cr3t"}}
+	if err := dryRunClient(cronJob, opts, &b); err != nil {
+		t.Fatalf("dryRunClient error: %s", err)
+	}
+	if len(clientset.Actions()) != 0 {
+		t.Errorf("wants no action but got %+v", clientset.Actions())
+	}
+	output := b.String()
+	if strings.Contains(output, "s3cr3t") {
+		t.Errorf("output must not contain the secret value:\n%s", output)
+	}
+	if !strings.Contains(output, "PASSWORD: '***'") {
+		t.Errorf("output must contain the masked secret value:\n%s", output)
+	}
+	if !strings.Contains(output, "kind: Job") {
+		t.Errorf("output must contain the Job:\n%s", output)
+	}
+}
+
+func TestDryRunServer(t *testing.T) {
+	cronJob := newExampleCronJob()
+	clientset := fake.NewClientset(cronJob)
+	var b bytes.Buffer
+	opts := RunCronJobOptions{SecretEnv: map[string]string{"PASSWORD": "s3cr3t"}}
+	if err := dryRunServer(t.Context(), clientset, cronJob, opts, &b); err != nil {
+		t.Fatalf("dryRunServer error: %s", err)
+	}
+	var createdResources []string
+	for _, action := range clientset.Actions() {
+		createAction, ok := action.(k8stesting.CreateActionImpl)
+		if !ok {
+			continue
+		}
+		createdResources = append(createdResources, createAction.GetResource().Resource)
+		if diff := cmp.Diff([]string{metav1.DryRunAll}, createAction.GetCreateOptions().DryRun); diff != "" {
+			t.Errorf("DryRun of %s mismatch (-want +got):\n%s", createAction.GetResource().Resource, diff)
+		}
+	}
+	if diff := cmp.Diff([]string{"secrets", "jobs"}, createdResources); diff != "" {
+		t.Errorf("created resources mismatch (-want +got):\n%s", diff)
+	}
+	output := b.String()
+	if strings.Contains(output, "s3cr3t") {
+		t.Errorf("output must not contain the secret value:\n%s", output)
+	}
+	if !strings.Contains(output, "PASSWORD: '***'") {
+		t.Errorf("output must contain the masked secret value:\n%s", output)
+	}
+}
@@ -0,0 +1,176 @@
+package runner
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// NewFileContainerLogger returns a ContainerLogger which writes the logs of each container
+// into the file of "<dir>/<pod>/<container>.log".
+// If gzipEnabled is true, it compresses the file and appends ".gz" to the file name.
+// The file is opened in append mode, and closed when the stream is reached to EOF.
+func NewFileContainerLogger(dir string, gzipEnabled bool) ContainerLogger {
+	return &fileContainerLogger{
+		dir:         dir,
+		gzipEnabled: gzipEnabled,
+		files:       make(map[containerKey]*containerLogFile),
+	}
+}
+
+type containerKey struct {
+	podName       string
+	containerName string
+}
+
+type fileContainerLogger struct {
+	dir         string
+	gzipEnabled bool
+
+	mu    sync.Mutex
+	files map[containerKey]*containerLogFile
+}
+
+// structured returns true because it writes nothing to stdout.
+func (l *fileContainerLogger) structured() bool { return true }
+
+func (l *fileContainerLogger) Handle(record ContainerLogRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := containerKey{podName: record.PodName, containerName: record.ContainerName}
+	f, ok := l.files[key]
+	if !ok {
+		var err error
+		f, err = l.open(key)
+		if err != nil {
+			slog.Error("Failed to open the container log file", "error", err)
+			// Do not retry for each line.
+			l.files[key] = nil
+			return
+		}
+		l.files[key] = f
+	}
+	if f == nil {
+		return
+	}
+	// Restore the original line if it was split.
+	line := record.Message
+	if !record.Partial {
+		line += "\n"
+	}
+	if _, err := f.buf.WriteString(line); err != nil {
+		slog.Error("Failed to write the container log file", "path", f.path, "error", err)
+	}
+}
+
+func (l *fileContainerLogger) HandleStreamEnd(end ContainerLogStreamEnd) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := containerKey{podName: end.PodName, containerName: end.ContainerName}
+	f := l.files[key]
+	delete(l.files, key)
+	if f == nil {
+		return
+	}
+	if err := f.close(); err != nil {
+		slog.Error("Failed to close the container log file", "path", f.path, "error", err)
+		return
+	}
+	slog.Info("Wrote the container log to the file", "path", f.path)
+}
+
+func (l *fileContainerLogger) open(key containerKey) (*containerLogFile, error) {
+	podDir := filepath.Join(l.dir, key.podName)
+	if err := os.MkdirAll(podDir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create the directory: %w", err)
+	}
+	name := key.containerName + ".log"
+	if l.gzipEnabled {
+		name += ".gz"
+	}
+	p := filepath.Join(podDir, name)
+	file, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the file: %w", err)
+	}
+	f := &containerLogFile{path: p, file: file}
+	if l.gzipEnabled {
+		// Appending to an existing file produces a multi-member gzip stream, which is valid.
+		f.gzip = gzip.NewWriter(file)
+		f.buf = bufio.NewWriter(f.gzip)
+	} else {
+		f.buf = bufio.NewWriter(file)
+	}
+	return f, nil
+}
+
+type containerLogFile struct {
+	path string
+	file *os.File
+	gzip *gzip.Writer
+	buf  *bufio.Writer
+}
+
+func (f *containerLogFile) close() error {
+	if err := f.buf.Flush(); err != nil {
+		_ = f.file.Close()
+		return fmt.Errorf("could not flush the buffer: %w", err)
+	}
+	if f.gzip != nil {
+		if err := f.gzip.Close(); err != nil {
+			_ = f.file.Close()
+			return fmt.Errorf("could not close the gzip writer: %w", err)
+		}
+	}
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("could not close the file: %w", err)
+	}
+	return nil
+}
+
+// NewMultiContainerLogger returns a ContainerLogger which forwards the logs to all loggers.
+// It also forwards the end of stream to the loggers which implement ContainerLogStreamEndHandler.
+func NewMultiContainerLogger(loggers ...ContainerLogger) ContainerLogger {
+	return multiContainerLogger(loggers)
+}
+
+type multiContainerLogger []ContainerLogger
+
+// structured returns true if all the loggers are structured.
+func (m multiContainerLogger) structured() bool {
+	for _, l := range m {
+		if !isStructuredContainerLogger(l) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m multiContainerLogger) Handle(record ContainerLogRecord) {
+	for _, l := range m {
+		l.Handle(record)
+	}
+}
+
+func (m multiContainerLogger) HandleStreamEnd(end ContainerLogStreamEnd) {
+	for _, l := range m {
+		if h, ok := l.(ContainerLogStreamEndHandler); ok {
+			h.HandleStreamEnd(end)
+		}
+	}
+}
+
+// NewDiscardContainerLogger returns a ContainerLogger which discards all logs.
+func NewDiscardContainerLogger() ContainerLogger {
+	return discardContainerLogger{}
+}
+
+type discardContainerLogger struct{}
+
+func (discardContainerLogger) structured() bool { return true }
+
+func (discardContainerLogger) Handle(ContainerLogRecord) {}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFileContainerLogger(t *testing.T) {
+	write := func(l ContainerLogger, podName, containerName string, messages ...string) {
+		for _, message := range messages {
+			l.Handle(ContainerLogRecord{PodName: podName, ContainerName: containerName, Message: message})
+		}
+		l.(ContainerLogStreamEndHandler).HandleStreamEnd(
+			ContainerLogStreamEnd{PodName: podName, ContainerName: containerName})
+	}
+
+	t.Run("plain", func(t *testing.T) {
+		dir := t.TempDir()
+		l := NewFileContainerLogger(dir, false)
+		write(l, "pod-1", "main", "hello", "world")
+		write(l, "pod-1", "sidecar", "foo")
+		// restarted container
+		write(l, "pod-1", "main", "again")
+
+		for p, want := range map[string]string{
+			"pod-1/main.log":    "hello\nworld\nagain\n",
+			"pod-1/sidecar.log": "foo\n",
+		} {
+			got, err := os.ReadFile(filepath.Join(dir, p))
+			if err != nil {
+				t.Fatalf("could not read the file: %s", err)
+			}
+			if diff := cmp.Diff(want, string(got)); diff != "" {
+				t.Errorf("%s mismatch (-want +got):\n%s", p, diff)
+			}
+		}
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		dir := t.TempDir()
+		l := NewFileContainerLogger(dir, true)
+		write(l, "pod-1", "main", "hello")
+		write(l, "pod-1", "main", "again")
+
+		f, err := os.Open(filepath.Join(dir, "pod-1/main.log.gz"))
+		if err != nil {
+			t.Fatalf("could not open the file: %s", err)
+		}
+		defer f.Close()
+		r, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("could not open the gzip reader: %s", err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("could not read the file: %s", err)
+		}
+		if diff := cmp.Diff("hello\nagain\n", string(got)); diff != "" {
+			t.Errorf("content mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"cmp"
+	"encoding/base64"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// NewRedactingContainerLogger returns a ContainerLogger which replaces the secret values with "***".
+// It also replaces the common encodings of the values, such as base64 and URL encoding.
+// A value split into the records with ContainerLogRecord.Partial may not be replaced.
+func NewRedactingContainerLogger(inner ContainerLogger, values []string) ContainerLogger {
+	var oldnew []string
+	for _, v := range secretVariants(values) {
+		oldnew = append(oldnew, v, "***")
+	}
+	return &redactingContainerLogger{inner: inner, replacer: strings.NewReplacer(oldnew...)}
+}
+
+type redactingContainerLogger struct {
+	inner    ContainerLogger
+	replacer *strings.Replacer
+}
+
+func (l *redactingContainerLogger) structured() bool {
+	return isStructuredContainerLogger(l.inner)
+}
+
+func (l *redactingContainerLogger) Handle(record ContainerLogRecord) {
+	record.Message = l.replacer.Replace(record.Message)
+	l.inner.Handle(record)
+}
+
+func (l *redactingContainerLogger) HandleStreamEnd(end ContainerLogStreamEnd) {
+	if h, ok := l.inner.(ContainerLogStreamEndHandler); ok {
+		h.HandleStreamEnd(end)
+	}
+}
+
+// secretVariants returns the values and their encodings, longest first.
+// The replacer tries the patterns in order, so a longer one must come first.
+func secretVariants(values []string) []string {
+	var variants []string
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		variants = append(variants,
+			v,
+			base64.StdEncoding.EncodeToString([]byte(v)),
+			base64.RawStdEncoding.EncodeToString([]byte(v)),
+			base64.URLEncoding.EncodeToString([]byte(v)),
+			base64.RawURLEncoding.EncodeToString([]byte(v)),
+			url.QueryEscape(v),
+			url.PathEscape(v),
+		)
+	}
+	slices.SortFunc(variants, func(a, b string) int {
+		if c := cmp.Compare(len(b), len(a)); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	return slices.Compact(variants)
+}
+
+// secretValues returns the values of the secret environment variables.
+func secretValues(secretEnv map[string]string) []string {
+	var values []string
+	for _, v := range secretEnv {
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	slices.Sort(values)
+	return values
+}
@@ -0,0 +1,35 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRedactingContainerLogger(t *testing.T) {
+	var b bytes.Buffer
+	l := NewRedactingContainerLogger(NewPlainContainerLogger(&b), []string{"p@ss word", "s3cr3t"})
+	for _, message := range []string{
+		"+ echo s3cr3t",
+		"password=p@ss word",
+		"base64=cEBzcyB3b3Jk",
+		"base64url=czNjcjN0",
+		"query=p%40ss+word",
+		"path=p@ss%20word",
+		"no secret",
+	} {
+		l.Handle(ContainerLogRecord{Message: message})
+	}
+	want := `+ echo ***
+password=***
+base64=***
+base64url=***
+query=***
+path=***
+no secret
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
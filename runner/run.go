@@ -6,15 +6,23 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"math"
 	"os"
+	"time"
 
+	"github.com/int128/cronjob-runner/internal/ci"
+	"github.com/int128/cronjob-runner/internal/events"
 	"github.com/int128/cronjob-runner/internal/jobs"
 	"github.com/int128/cronjob-runner/internal/logs"
 	"github.com/int128/cronjob-runner/internal/pods"
+	"github.com/int128/cronjob-runner/internal/secrets"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
@@ -26,16 +34,88 @@ import (
 // RunCronJobOptions represents a set of options for RunJobFromCronJob.
 type RunCronJobOptions struct {
 	// Env is a map of environment variables injected to all containers of a Pod.
+	// See also EnvContainerNames.
 	// Optional.
 	Env map[string]string
 
 	// SecretEnv is a map of environment variables injected to all containers of a Pod via an ephemeral Secret.
+	// The values are masked in the container logs, unless DisableSecretEnvMasking is set.
 	// Optional.
 	SecretEnv map[string]string
 
+	// DisableSecretEnvMasking prints the values of SecretEnv in the container logs as-is.
+	// By default, they are replaced with "***". See WaitForJobOptions.MaskValues.
+	DisableSecretEnvMasking bool
+
+	// EnvContainerNames is a list of container names to inject Env and SecretEnv.
+	// Init containers and sidecar containers are also available.
+	// Default to all containers except init containers.
+	EnvContainerNames []string
+
+	// Command overrides the command of the container.
+	// If Command is set, the args of the container are replaced with Args.
+	// Optional.
+	Command []string
+
+	// Args overrides the args of the container.
+	// Optional.
+	Args []string
+
+	// ContainerName is the name of container to override Command and Args.
+	// Default to the first container.
+	ContainerName string
+
+	// Images is a map of container name to image, to override the images of the containers.
+	// Init containers are also available.
+	// Optional.
+	Images map[string]string
+
+	// Timeout is the maximum duration of the Job.
+	// It sets activeDeadlineSeconds of the Job, and deletes the Job on expiry.
+	// See WaitForJobOptions.Timeout.
+	// Default to no timeout.
+	Timeout time.Duration
+
+	// OnCancel is the policy to clean up the Job when the context is canceled.
+	// Default to CancelPolicyKeep.
+	OnCancel CancelPolicy
+
+	// ShowNormalEvents shows the Normal events of the Job and its Pods.
+	// By default, it shows only the Warning events.
+	ShowNormalEvents bool
+
+	// DryRun is the dry-run mode.
+	// If set, it prints the Job and Secret without running the Job.
+	// Default to DryRunNone.
+	DryRun DryRunMode
+
+	// ContainerLogMaxLineSize is the maximum size of a container log record in bytes.
+	// A longer line is split into the records with ContainerLogRecord.Partial.
+	// Default to 256KiB.
+	ContainerLogMaxLineSize int
+
+	// ContainerLogMaxRetryDuration is the maximum duration to retry tailing a container log on errors.
+	// When exhausted, ContainerLogStreamEndHandler receives ErrContainerLogStreamLost.
+	// Default to 5 minutes.
+	ContainerLogMaxRetryDuration time.Duration
+
 	// ContainerLogger is an implementation of ContainerLogger interface.
-	// Default to the defaultContainerLogger.
+	// Default to NewPlainContainerLogger(os.Stdout).
 	ContainerLogger ContainerLogger
+
+	// CI is the CI system to write the output for.
+	// It wraps the container logs of each container in a collapsible section,
+	// writes the annotations on failure and the summary of the run.
+	// The sections are written to stdout, so ContainerLogger should write to stdout.
+	// If ContainerLogger is NewJSONContainerLogger or NewDiscardContainerLogger, the sections are not written.
+	// Default to CIProviderNone.
+	CI CIProvider
+
+	// NeutralizeWorkflowCommands prevents the container logs from being interpreted
+	// as the workflow commands of GitHub Actions, such as ::add-mask:: or ::stop-commands::.
+	// The commands are written to stdout, so ContainerLogger should write to stdout.
+	// It is ignored if ContainerLogger is NewJSONContainerLogger or NewDiscardContainerLogger.
+	NeutralizeWorkflowCommands bool
 }
 
 // RunJobFromCronJob creates a Job from the existing CronJob, and waits for the completion.
@@ -46,18 +126,31 @@ type RunCronJobOptions struct {
 //   - Create a Job from the CronJob template.
 //   - Wait for the Job. See WaitForJob().
 //
+// If RunCronJobOptions.DryRun is set, it prints the Job and Secret, and returns nil.
+//
 // If the job is succeeded, it returns nil.
-// If the job is failed, it returns JobFailedError.
-// Otherwise, it returns an error.
-// If the context is canceled, it stops gracefully.
+// If the CronJob does not exist, it returns CronJobNotFoundError.
+// If the API server rejected the Job, it returns JobCreationError.
+// Otherwise, it returns an error of WaitForJob().
+// If the context is canceled, it stops gracefully and returns CanceledError.
 func RunJobFromCronJob(ctx context.Context, clientset kubernetes.Interface, namespace, cronJobName string, opts RunCronJobOptions) error {
 	cronJob, err := clientset.BatchV1().CronJobs(namespace).Get(ctx, cronJobName, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return CronJobNotFoundError{CronJobNamespace: namespace, CronJobName: cronJobName}
+	}
 	if err != nil {
-		return fmt.Errorf("get the CronJob: %w", err)
+		return fmt.Errorf("get the CronJob: %w", wrapAPIError(ctx, err))
 	}
 	slog.Info("Found the CronJob",
 		slog.Group("cronJob", slog.String("namespace", cronJob.Namespace), slog.String("name", cronJob.Name)))
 
+	switch opts.DryRun {
+	case DryRunClient:
+		return dryRunClient(cronJob, opts, os.Stdout)
+	case DryRunServer:
+		return dryRunServer(ctx, clientset, cronJob, opts, os.Stdout)
+	}
+
 	if len(opts.SecretEnv) > 0 {
 		if err := runJobFromCronJobWithSecret(ctx, clientset, cronJob, opts); err != nil {
 			return fmt.Errorf("runJobFromCronJobWithSecret: %w", err)
@@ -65,34 +158,31 @@ func RunJobFromCronJob(ctx context.Context, clientset kubernetes.Interface, name
 		return nil
 	}
 
-	job, err := clientset.BatchV1().Jobs(namespace).Create(ctx,
-		jobs.NewFromCronJob(cronJob, opts.Env, nil, nil),
-		metav1.CreateOptions{},
-	)
+	jobToCreate, err := jobs.NewFromCronJob(cronJob, newJobOptions(opts, nil))
 	if err != nil {
-		return fmt.Errorf("create a Job: %w", err)
+		return fmt.Errorf("could not create a Job from the CronJob: %w", err)
+	}
+	job, err := clientset.BatchV1().Jobs(namespace).Create(ctx, jobToCreate, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("create a Job: %w", wrapCreationError(ctx, err))
 	}
 	slog.Info("Created a Job",
 		slog.Group("job", slog.String("namespace", job.Namespace), slog.String("name", job.Name)))
-	printJobYAML(job)
+	printJobYAML(job, ci.New(ci.Name(opts.CI)))
 
-	if err := WaitForJob(ctx, clientset, job, WaitForJobOptions{ContainerLogger: opts.ContainerLogger}); err != nil {
+	if err := WaitForJob(ctx, clientset, job, newWaitForJobOptions(opts)); err != nil {
 		return fmt.Errorf("run the Job: %w", err)
 	}
 	return nil
 }
 
 func runJobFromCronJobWithSecret(ctx context.Context, clientset kubernetes.Interface, cronJob *batchv1.CronJob, opts RunCronJobOptions) error {
-	secret, err := clientset.CoreV1().Secrets(cronJob.Namespace).Create(ctx, &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace:    cronJob.Namespace,
-			GenerateName: fmt.Sprintf("%s-", cronJob.Name),
-		},
-		Immutable:  ptr.To(true),
-		StringData: opts.SecretEnv,
-	}, metav1.CreateOptions{})
+	secret, err := clientset.CoreV1().Secrets(cronJob.Namespace).Create(ctx,
+		secrets.NewFromCronJob(cronJob, opts.SecretEnv),
+		metav1.CreateOptions{},
+	)
 	if err != nil {
-		return fmt.Errorf("create a Secret: %w", err)
+		return fmt.Errorf("create a Secret: %w", wrapCreationError(ctx, err))
 	}
 	secretAttr := slog.Group("secret", slog.String("namespace", secret.Namespace), slog.String("name", secret.Name))
 	slog.Info("Created a Secret", secretAttr)
@@ -105,16 +195,17 @@ func runJobFromCronJobWithSecret(ctx context.Context, clientset kubernetes.Inter
 		slog.Info("Deleted the Secret", secretAttr)
 	}()
 
-	job, err := clientset.BatchV1().Jobs(cronJob.Namespace).Create(ctx,
-		jobs.NewFromCronJob(cronJob, opts.Env, opts.SecretEnv, &corev1.LocalObjectReference{Name: secret.Name}),
-		metav1.CreateOptions{},
-	)
+	jobToCreate, err := jobs.NewFromCronJob(cronJob, newJobOptions(opts, &corev1.LocalObjectReference{Name: secret.Name}))
 	if err != nil {
-		return fmt.Errorf("create a Job: %w", err)
+		return fmt.Errorf("could not create a Job from the CronJob: %w", err)
+	}
+	job, err := clientset.BatchV1().Jobs(cronJob.Namespace).Create(ctx, jobToCreate, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("create a Job: %w", wrapCreationError(ctx, err))
 	}
 	slog.Info("Created a Job",
 		slog.Group("job", slog.String("namespace", job.Namespace), slog.String("name", job.Name)))
-	printJobYAML(job)
+	printJobYAML(job, ci.New(ci.Name(opts.CI)))
 
 	secret, err = clientset.CoreV1().Secrets(cronJob.Namespace).Apply(ctx,
 		corev1ac.Secret(secret.Name, cronJob.Namespace).WithOwnerReferences(
@@ -128,21 +219,113 @@ func runJobFromCronJobWithSecret(ctx context.Context, clientset kubernetes.Inter
 		metav1.ApplyOptions{FieldManager: "cronjob-runner"},
 	)
 	if err != nil {
-		return fmt.Errorf("apply the owner reference to the Secret: %w", err)
+		if ctx.Err() != nil {
+			// The Job has been created, so clean it up in the policy.
+			return fmt.Errorf("apply the owner reference to the Secret: %w", cancelJob(ctx, clientset, job, opts.OnCancel))
+		}
+		return fmt.Errorf("apply the owner reference to the Secret: %w", withJob(wrapAPIError(ctx, err), job))
 	}
 	slog.Info("Applied the owner reference to the Secret", secretAttr)
 
-	if err := WaitForJob(ctx, clientset, job, WaitForJobOptions{ContainerLogger: opts.ContainerLogger}); err != nil {
+	if err := WaitForJob(ctx, clientset, job, newWaitForJobOptions(opts)); err != nil {
 		return fmt.Errorf("run the Job: %w", err)
 	}
 	return nil
 }
 
+func newJobOptions(opts RunCronJobOptions, secretRef *corev1.LocalObjectReference) jobs.Options {
+	jobOptions := jobs.Options{
+		Env:               opts.Env,
+		EnvContainerNames: opts.EnvContainerNames,
+		Command:           opts.Command,
+		Args:              opts.Args,
+		ContainerName:     opts.ContainerName,
+		Images:            opts.Images,
+	}
+	if opts.Timeout > 0 {
+		jobOptions.ActiveDeadlineSeconds = ptr.To(int64(math.Ceil(opts.Timeout.Seconds())))
+	}
+	if secretRef != nil {
+		jobOptions.SecretEnv = opts.SecretEnv
+		jobOptions.SecretRef = secretRef
+	}
+	return jobOptions
+}
+
+func newWaitForJobOptions(opts RunCronJobOptions) WaitForJobOptions {
+	var maskValues []string
+	if !opts.DisableSecretEnvMasking {
+		maskValues = secretValues(opts.SecretEnv)
+	}
+	return WaitForJobOptions{
+		Timeout:                      opts.Timeout,
+		OnCancel:                     opts.OnCancel,
+		ShowNormalEvents:             opts.ShowNormalEvents,
+		ContainerLogMaxLineSize:      opts.ContainerLogMaxLineSize,
+		ContainerLogMaxRetryDuration: opts.ContainerLogMaxRetryDuration,
+		ContainerLogger:              opts.ContainerLogger,
+		CI:                           opts.CI,
+		NeutralizeWorkflowCommands:   opts.NeutralizeWorkflowCommands,
+		MaskValues:                   maskValues,
+	}
+}
+
 // WaitForJobOptions represents a set of options for WaitForJob.
 type WaitForJobOptions struct {
+	// Timeout is the maximum duration to wait for the Job.
+	// On expiry, it deletes the Job and returns JobDeadlineExceededError.
+	// Default to no timeout.
+	Timeout time.Duration
+
+	// OnCancel is the policy to clean up the Job when the context is canceled.
+	// Default to CancelPolicyKeep.
+	OnCancel CancelPolicy
+
+	// ShowNormalEvents shows the Normal events of the Job and its Pods.
+	// By default, it shows only the Warning events.
+	ShowNormalEvents bool
+
+	// ContainerLogSince shows the container logs newer than the relative duration.
+	// Default to all logs.
+	ContainerLogSince time.Duration
+
+	// ContainerLogTailLines shows the number of lines from the end of the container logs.
+	// Default to all logs.
+	ContainerLogTailLines *int64
+
+	// ContainerLogMaxLineSize is the maximum size of a container log record in bytes.
+	// A longer line is split into the records with ContainerLogRecord.Partial.
+	// Default to 256KiB.
+	ContainerLogMaxLineSize int
+
+	// ContainerLogMaxRetryDuration is the maximum duration to retry tailing a container log on errors.
+	// When exhausted, ContainerLogStreamEndHandler receives ErrContainerLogStreamLost.
+	// Default to 5 minutes.
+	ContainerLogMaxRetryDuration time.Duration
+
 	// ContainerLogger is an implementation of ContainerLogger interface.
-	// Default to the defaultContainerLogger.
+	// Default to NewPlainContainerLogger(os.Stdout).
 	ContainerLogger ContainerLogger
+
+	// CI is the CI system to write the output for.
+	// It wraps the container logs of each container in a collapsible section,
+	// writes the annotations on failure and the summary of the run.
+	// The sections are written to stdout, so ContainerLogger should write to stdout.
+	// If ContainerLogger is NewJSONContainerLogger or NewDiscardContainerLogger, the sections are not written.
+	// Default to CIProviderNone.
+	CI CIProvider
+
+	// NeutralizeWorkflowCommands prevents the container logs from being interpreted
+	// as the workflow commands of GitHub Actions, such as ::add-mask:: or ::stop-commands::.
+	// The commands are written to stdout, so ContainerLogger should write to stdout.
+	// It is ignored if ContainerLogger is NewJSONContainerLogger or NewDiscardContainerLogger.
+	NeutralizeWorkflowCommands bool
+
+	// MaskValues is a list of values to replace with "***" in the container logs.
+	// Their base64 and URL encoded forms are also replaced.
+	// On GitHub Actions, they are also registered by ::add-mask::.
+	// Optional.
+	MaskValues []string
 }
 
 // WaitForJob waits for the completion of the Job.
@@ -150,68 +333,252 @@ type WaitForJobOptions struct {
 // It waits for the Job as follows:
 //
 //   - Show the statuses of Job, Pod(s) and container(s) when changed.
+//   - Show the Warning events of Job and Pod(s).
 //   - Tail the log streams of all containers.
 //   - Wait for the Job to be succeeded or failed.
+//   - If WaitForJobOptions.Timeout is exceeded, delete the Job and wait for the container logs.
+//   - If the context is canceled, clean up the Job in WaitForJobOptions.OnCancel.
 //
 // If the job is succeeded, it returns nil.
 // If the job is failed, it returns JobFailedError.
-// Otherwise, it returns an error.
-// If the context is canceled, it stops gracefully.
+// If the job is deleted before finished, it returns JobDeletedError.
+// If the job has exceeded activeDeadlineSeconds or WaitForJobOptions.Timeout, it returns JobDeadlineExceededError.
+// If the informers could not be started or the API server is unreachable, it returns InfrastructureError with the Job.
+// If the context is canceled, it stops gracefully and returns CanceledError.
 func WaitForJob(ctx context.Context, clientset kubernetes.Interface, job *batchv1.Job, opts WaitForJobOptions) error {
+	var c summaryCollector
+	err := withJob(waitForJob(ctx, clientset, job, opts, &c), job)
+	// All background workers have been stopped here.
+	printSummary(c.collect(job, err), ci.New(ci.Name(opts.CI)))
+	return err
+}
+
+func waitForJob(ctx context.Context, clientset kubernetes.Interface, job *batchv1.Job, opts WaitForJobOptions, c *summaryCollector) error {
 	if opts.ContainerLogger == nil {
-		opts.ContainerLogger = defaultContainerLogger{}
+		opts.ContainerLogger = NewPlainContainerLogger(os.Stdout)
+	}
+	provider := ci.New(ci.Name(opts.CI))
+	// If the container logs are structured such as NDJSON, do not write the commands to stdout.
+	// The command guard is not needed, because the JSON logger escapes the commands in a line.
+	structured := isStructuredContainerLogger(opts.ContainerLogger)
+	commandWriter := os.Stdout
+	if structured {
+		commandWriter = os.Stderr
+	}
+	if len(opts.MaskValues) > 0 {
+		for _, v := range secretVariants(opts.MaskValues) {
+			provider.AddMask(commandWriter, v)
+		}
+		opts.ContainerLogger = NewRedactingContainerLogger(opts.ContainerLogger, opts.MaskValues)
+	}
+	if opts.NeutralizeWorkflowCommands && !structured {
+		opts.ContainerLogger = ci.NewCommandGuard(os.Stdout, opts.ContainerLogger)
+	}
+	// Write the annotations to the same stream as the sections, to keep the order in the CI log.
+	annotator := provider.NewAnnotator(commandWriter)
+	if opts.CI != CIProviderNone && !structured {
+		opts.ContainerLogger = ci.NewSectionLogger(os.Stdout, provider, opts.ContainerLogger, ci.DefaultMaxBufferSize)
 	}
 
 	stopCh := make(chan struct{})
 	containerStartedCh := make(chan pods.ContainerStartedEvent)
-	jobFinishedCh := make(chan batchv1.JobConditionType)
+	jobFinishedCh := make(chan batchv1.JobCondition)
+	jobDeletedCh := make(chan *batchv1.Job)
 	var informerWaiter, containerLoggerWaiter wait.Group
 	defer func() {
 		close(stopCh)
 		informerWaiter.Wait()        // depends on close(stopCh)
 		close(containerStartedCh)    // depends on informerWaiter
 		close(jobFinishedCh)         // depends on informerWaiter
+		close(jobDeletedCh)          // depends on informerWaiter
 		containerLoggerWaiter.Wait() // depends on close(containerStartedCh)
+		if closer, ok := annotator.(io.Closer); ok {
+			_ = closer.Close() // depends on informerWaiter
+		}
 		slog.Info("Stopped all background workers")
 	}()
 
+	tailOptions := logs.TailOptions{
+		TailLines:        opts.ContainerLogTailLines,
+		MaxLineSize:      opts.ContainerLogMaxLineSize,
+		MaxRetryDuration: opts.ContainerLogMaxRetryDuration,
+	}
+	if opts.ContainerLogSince > 0 {
+		tailOptions.SinceSeconds = ptr.To(int64(math.Ceil(opts.ContainerLogSince.Seconds())))
+	}
 	containerLoggerWaiter.Start(func() {
 		// When a container is started, tail the container logs.
 		for containerStartedEvent := range containerStartedCh {
 			e := containerStartedEvent
 			containerLoggerWaiter.Start(func() {
-				logs.Tail(ctx, clientset, e.Namespace, e.PodName, e.ContainerName, opts.ContainerLogger)
+				source := logs.Source{
+					Namespace:       e.Namespace,
+					PodName:         e.PodName,
+					ContainerName:   e.ContainerName,
+					ContainerType:   e.ContainerType,
+					CompletionIndex: e.CompletionIndex,
+					PodRetryAttempt: e.PodRetryAttempt,
+					NodeName:        e.NodeName,
+				}
+				logs.Tail(ctx, clientset, source, opts.ContainerLogger, tailOptions)
 			})
 		}
 	})
-	podInformer, err := pods.StartInformer(clientset, job.Namespace, job.Name, stopCh, containerStartedCh)
+	watchErrors := newWatchErrorMonitor(watchErrorTimeout)
+	podInformer, err := pods.StartInformer(clientset, job.Namespace, job.Name, stopCh, containerStartedCh, annotator, watchErrors.handle)
 	if err != nil {
-		return fmt.Errorf("start the pod informer: %w", err)
+		return InfrastructureError{Err: fmt.Errorf("start the pod informer: %w", err)}
 	}
 	informerWaiter.Start(podInformer.Shutdown)
+	c.podInformer = podInformer
+	// The event informer determines the pods of the Job from the pod informer.
+	if err := waitForCacheSync(ctx, podInformer, informerSyncTimeout, watchErrors); err != nil {
+		if ctx.Err() != nil {
+			return cancelJob(ctx, clientset, job, opts.OnCancel)
+		}
+		return err
+	}
 
-	jobInformer, err := jobs.StartInformer(clientset, job.Namespace, job.Name, stopCh, jobFinishedCh)
+	// The events are diagnostics, so the run does not depend on the event informer.
+	// It has its own stop channel to give up if it cannot sync, such as lack of the permission.
+	eventStopCh := make(chan struct{})
+	eventWatchErrors := newWatchErrorMonitor(watchErrorTimeout)
+	eventInformer, err := events.StartInformer(clientset, job.Namespace, job.Name, podInformer.IsJobPod, opts.ShowNormalEvents, eventStopCh, eventWatchErrors.handle)
 	if err != nil {
-		return fmt.Errorf("start the job informer: %w", err)
+		return InfrastructureError{Err: fmt.Errorf("start the event informer: %w", err)}
+	}
+	informerWaiter.Start(func() {
+		defer eventInformer.Shutdown() // depends on close(eventStopCh)
+		defer close(eventStopCh)
+		stopCtx := wait.ContextForChannel(stopCh)
+		syncCtx, cancel := context.WithTimeout(stopCtx, informerSyncTimeout)
+		defer cancel()
+		for _, synced := range eventInformer.WaitForCacheSync(syncCtx.Done()) {
+			if !synced {
+				if stopCtx.Err() == nil {
+					slog.Warn("Could not watch the events. The events are not shown", "error", eventWatchErrors.lastError())
+				}
+				return
+			}
+		}
+		<-stopCh
+	})
+	c.eventInformer = eventInformer
+
+	jobInformer, err := jobs.StartInformer(clientset, job.Namespace, job.Name, stopCh, jobFinishedCh, jobDeletedCh, annotator, watchErrors.handle)
+	if err != nil {
+		return InfrastructureError{Err: fmt.Errorf("start the job informer: %w", err)}
 	}
 	informerWaiter.Start(jobInformer.Shutdown)
+	c.jobInformer = jobInformer
+
+	if err := waitForCacheSync(ctx, jobInformer, informerSyncTimeout, watchErrors); err != nil {
+		if ctx.Err() != nil {
+			return cancelJob(ctx, clientset, job, opts.OnCancel)
+		}
+		return err
+	}
+
+	var timeoutCh <-chan time.Time
+	if opts.Timeout > 0 {
+		timer := time.NewTimer(opts.Timeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
 
 	select {
-	case jobConditionType := <-jobFinishedCh:
-		if jobConditionType == batchv1.JobFailed {
-			return JobFailedError{JobNamespace: job.Namespace, JobName: job.Name}
+	case jobCondition := <-jobFinishedCh:
+		if jobCondition.Type == batchv1.JobFailed && jobCondition.Reason == batchv1.JobReasonDeadlineExceeded {
+			return JobDeadlineExceededError{JobNamespace: job.Namespace, JobName: job.Name}
+		}
+		if jobCondition.Type == batchv1.JobFailed {
+			return JobFailedError{
+				JobNamespace:         job.Namespace,
+				JobName:              job.Name,
+				TerminatedContainers: terminatedContainersOf(ctx, clientset, job, podInformer),
+			}
 		}
 		return nil
+	case deletedJob := <-jobDeletedCh:
+		return JobDeletedError{JobNamespace: job.Namespace, JobName: job.Name, Status: deletedJob.Status}
+	case <-timeoutCh:
+		slog.Info("Job exceeded the timeout",
+			slog.Group("job", slog.String("namespace", job.Namespace), slog.String("name", job.Name)),
+			slog.Duration("timeout", opts.Timeout))
+		// The container logs are tailed until the pods are terminated.
+		if err := deleteJobOnTimeout(clientset, job); err != nil {
+			slog.Warn("Failed to delete the Job", "error", err)
+		}
+		return JobDeadlineExceededError{JobNamespace: job.Namespace, JobName: job.Name}
+	case err := <-watchErrors.errCh:
+		return InfrastructureError{Err: err}
 	case <-ctx.Done():
-		slog.Info("Shutting down")
-		return ctx.Err()
+		return cancelJob(ctx, clientset, job, opts.OnCancel)
+	}
+}
+
+// terminatedContainersOf returns the terminated states of containers in the Pod(s) of the Job.
+// The pod informer and job informer are independent, so the pod informer may not
+// have received the last termination when the Job is failed. It confirms them by listing the Pods.
+func terminatedContainersOf(ctx context.Context, clientset kubernetes.Interface, job *batchv1.Job, podInformer pods.Informer) []ContainerTerminatedState {
+	observed := podInformer.TerminatedContainers()
+	listed, err := pods.ListTerminatedContainers(ctx, clientset, job.Namespace, job.Name)
+	if err != nil {
+		slog.Warn("Failed to list the terminated containers", "error", err)
+		return observed
+	}
+	return pods.MergeTerminatedContainers(observed, listed)
+}
+
+// withJob sets the Job to InfrastructureError, because the Job may be still running in the cluster.
+func withJob(err error, job *batchv1.Job) error {
+	var infrastructureError InfrastructureError
+	if errors.As(err, &infrastructureError) && infrastructureError.JobName == "" {
+		infrastructureError.JobNamespace = job.Namespace
+		infrastructureError.JobName = job.Name
+		return infrastructureError
+	}
+	return err
+}
+
+// wrapAPIError wraps the error returned by the API server into the typed error.
+func wrapAPIError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return CanceledError{Cause: context.Cause(ctx)}
+	}
+	if isInfrastructureError(err) {
+		return InfrastructureError{Err: err}
+	}
+	return err
+}
+
+// wrapCreationError wraps the error returned by the API server on creating a resource.
+// If the request is rejected by the API server, it returns JobCreationError.
+func wrapCreationError(ctx context.Context, err error) error {
+	if ctx.Err() != nil || isInfrastructureError(err) {
+		return wrapAPIError(ctx, err)
+	}
+	return JobCreationError{Err: err}
+}
+
+// isInfrastructureError returns true if the error is caused by the API server or the connection.
+func isInfrastructureError(err error) bool {
+	var apiStatus kerrors.APIStatus
+	if !errors.As(err, &apiStatus) {
+		// No response from the API server, such as connection refused
+		return true
 	}
+	return kerrors.IsServerTimeout(err) ||
+		kerrors.IsTimeout(err) ||
+		kerrors.IsTooManyRequests(err) ||
+		kerrors.IsInternalError(err) ||
+		kerrors.IsServiceUnavailable(err) ||
+		kerrors.IsUnexpectedServerError(err)
 }
 
-func printJobYAML(job *batchv1.Job) {
-	// Group for GitHub Actions
-	// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#grouping-log-lines
-	_, _ = fmt.Fprintln(os.Stderr, "::group::Job YAML")
+func printJobYAML(job *batchv1.Job, provider ci.Provider) {
+	// Collapse the section in CI
+	provider.BeginSection(os.Stderr, "job_yaml", "Job YAML")
 	jobs.PrintYAML(job, os.Stderr)
-	_, _ = fmt.Fprintln(os.Stderr, "::endgroup::")
+	provider.EndSection(os.Stderr, "job_yaml")
 }
@@ -0,0 +1,165 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestWaitForJob(t *testing.T) {
+	t.Run("job is already completed", func(t *testing.T) {
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-job"},
+			Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+			},
+		}
+		clientset := fake.NewClientset(job)
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+		if err := WaitForJob(ctx, clientset, job, WaitForJobOptions{}); err != nil {
+			t.Errorf("WaitForJob wants nil but got %s", err)
+		}
+	})
+
+	t.Run("job is already failed", func(t *testing.T) {
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-job"},
+			Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}},
+			},
+		}
+		clientset := fake.NewClientset(job)
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+		err := WaitForJob(ctx, clientset, job, WaitForJobOptions{})
+		if !errors.As(err, &JobFailedError{}) {
+			t.Errorf("WaitForJob wants JobFailedError but got %#v", err)
+		}
+	})
+
+	t.Run("events are forbidden", func(t *testing.T) {
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-job"},
+			Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+			},
+		}
+		clientset := fake.NewClientset(job)
+		clientset.PrependReactor("list", "events", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, kerrors.NewForbidden(schema.GroupResource{Group: "events.k8s.io", Resource: "events"}, "", errors.New("forbidden"))
+		})
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+		if err := WaitForJob(ctx, clientset, job, WaitForJobOptions{}); err != nil {
+			t.Errorf("WaitForJob wants nil but got %s", err)
+		}
+	})
+
+	t.Run("exit code of the failed container", func(t *testing.T) {
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-job"},
+			Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}},
+			},
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "example-job-abcde",
+				Labels:    map[string]string{"batch.kubernetes.io/job-name": "example-job"},
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "app",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}},
+				}},
+			},
+		}
+		clientset := fake.NewClientset(job, pod)
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+		err := WaitForJob(ctx, clientset, job, WaitForJobOptions{})
+		var jobFailedError JobFailedError
+		if !errors.As(err, &jobFailedError) {
+			t.Fatalf("WaitForJob wants JobFailedError but got %#v", err)
+		}
+		if got := jobFailedError.ExitCode(); got != 137 {
+			t.Errorf("ExitCode wants 137 but got %d", got)
+		}
+	})
+
+	t.Run("job exceeds the timeout", func(t *testing.T) {
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-job"}}
+		clientset := fake.NewClientset(job)
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+		err := WaitForJob(ctx, clientset, job, WaitForJobOptions{Timeout: 100 * time.Millisecond})
+		if !errors.As(err, &JobDeadlineExceededError{}) {
+			t.Errorf("WaitForJob wants JobDeadlineExceededError but got %#v", err)
+		}
+		_, err = clientset.BatchV1().Jobs("default").Get(t.Context(), "example-job", metav1.GetOptions{})
+		if !kerrors.IsNotFound(err) {
+			t.Errorf("Job must be deleted but got %v", err)
+		}
+	})
+
+	t.Run("summary is appended to GITHUB_STEP_SUMMARY", func(t *testing.T) {
+		stepSummaryPath := filepath.Join(t.TempDir(), "summary.md")
+		t.Setenv("GITHUB_STEP_SUMMARY", stepSummaryPath)
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-job"},
+			Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+			},
+		}
+		clientset := fake.NewClientset(job)
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+		if err := WaitForJob(ctx, clientset, job, WaitForJobOptions{CI: CIProviderGitHubActions}); err != nil {
+			t.Fatalf("WaitForJob wants nil but got %s", err)
+		}
+		b, err := os.ReadFile(stepSummaryPath)
+		if err != nil {
+			t.Fatalf("could not read the step summary: %s", err)
+		}
+		if !strings.HasPrefix(string(b), "### Job default/example-job: Succeeded") {
+			t.Errorf("unexpected step summary:\n%s", b)
+		}
+	})
+}
+
+func TestWithJob(t *testing.T) {
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-job"}}
+
+	t.Run("infrastructure error", func(t *testing.T) {
+		err := withJob(InfrastructureError{Err: errors.New("connection refused")}, job)
+		var infrastructureError InfrastructureError
+		if !errors.As(err, &infrastructureError) {
+			t.Fatalf("withJob wants InfrastructureError but got %#v", err)
+		}
+		if infrastructureError.JobNamespace != "default" || infrastructureError.JobName != "example-job" {
+			t.Errorf("Job wants default/example-job but got %s/%s", infrastructureError.JobNamespace, infrastructureError.JobName)
+		}
+	})
+
+	t.Run("other error", func(t *testing.T) {
+		want := CanceledError{Cause: context.Canceled}
+		if err := withJob(want, job); !errors.Is(err, want) {
+			t.Errorf("withJob wants %#v but got %#v", want, err)
+		}
+	})
+}
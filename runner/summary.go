@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/int128/cronjob-runner/internal/ci"
+	"github.com/int128/cronjob-runner/internal/events"
+	"github.com/int128/cronjob-runner/internal/jobs"
+	"github.com/int128/cronjob-runner/internal/pods"
+	"github.com/int128/cronjob-runner/internal/summary"
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+// summaryCollector holds the informers to collect the summary after they are stopped.
+type summaryCollector struct {
+	podInformer   pods.Informer
+	eventInformer events.Informer
+	jobInformer   jobs.Informer
+}
+
+// collect returns the summary of the run, or nil if the informers were not started.
+func (c *summaryCollector) collect(job *batchv1.Job, err error) *summary.Summary {
+	if c.podInformer == nil || c.eventInformer == nil || c.jobInformer == nil {
+		return nil
+	}
+	lastKnownJob := c.jobInformer.Job()
+	if lastKnownJob == nil {
+		lastKnownJob = job
+	}
+	s := summary.New(lastKnownJob, c.podInformer.Pods(), c.eventInformer.ImagePulls(), resultOf(err), time.Now())
+	return &s
+}
+
+// resultOf returns the result of the run from the error of WaitForJob.
+func resultOf(err error) string {
+	switch {
+	case err == nil:
+		return "Succeeded"
+	case errors.As(err, &JobFailedError{}):
+		return "Failed"
+	case errors.As(err, &JobDeadlineExceededError{}):
+		return "DeadlineExceeded"
+	case errors.As(err, &JobDeletedError{}):
+		return "Deleted"
+	case errors.As(err, &CanceledError{}):
+		return "Canceled"
+	}
+	return "Error"
+}
+
+// printSummary writes the summary to stderr and the CI system.
+func printSummary(s *summary.Summary, provider ci.Provider) {
+	if s == nil {
+		return
+	}
+	_, _ = fmt.Fprintln(os.Stderr)
+	if err := s.WriteTable(os.Stderr); err != nil {
+		slog.Warn("Failed to write the summary", "error", err)
+	}
+	if err := provider.WriteSummary(*s); err != nil {
+		slog.Warn("Failed to write the summary to the CI system", "error", err)
+	}
+}
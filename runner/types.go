@@ -3,30 +3,232 @@ package runner
 import (
 	"fmt"
 
+	"github.com/int128/cronjob-runner/internal/ci"
 	"github.com/int128/cronjob-runner/internal/logs"
+	"github.com/int128/cronjob-runner/internal/pods"
+	batchv1 "k8s.io/api/batch/v1"
 )
 
+// CronJobNotFoundError represents an error that the CronJob does not exist.
+type CronJobNotFoundError struct {
+	CronJobNamespace string
+	CronJobName      string
+}
+
+func (err CronJobNotFoundError) Error() string {
+	return fmt.Sprintf("cronjob %s/%s not found", err.CronJobNamespace, err.CronJobName)
+}
+
+// JobNotFoundError represents an error that the Job does not exist.
+type JobNotFoundError struct {
+	JobNamespace string
+	JobName      string
+}
+
+func (err JobNotFoundError) Error() string {
+	return fmt.Sprintf("job %s/%s not found", err.JobNamespace, err.JobName)
+}
+
+// JobCreationError represents an error that the API server rejected the Job or its Secret,
+// such as a validation error, a quota or a denial by an admission webhook.
+type JobCreationError struct {
+	Err error
+}
+
+func (err JobCreationError) Error() string {
+	return fmt.Sprintf("job creation rejected: %s", err.Err)
+}
+
+func (err JobCreationError) Unwrap() error {
+	return err.Err
+}
+
 // JobFailedError represents an error that the Job has failed.
 type JobFailedError struct {
 	JobNamespace string
 	JobName      string
+
+	// TerminatedContainers is a list of the terminated states of containers in the Pod(s) of the Job.
+	TerminatedContainers []ContainerTerminatedState
 }
 
 func (err JobFailedError) Error() string {
 	return fmt.Sprintf("job %s/%s failed", err.JobNamespace, err.JobName)
 }
 
+// ExitCode returns the exit code of the last failed main container.
+// If no main container has failed, it returns the exit code of the last failed init container.
+// If no container has failed, it returns 1.
+func (err JobFailedError) ExitCode() int {
+	if state := lastFailedContainer(err.TerminatedContainers, false); state != nil {
+		return int(state.ExitCode)
+	}
+	if state := lastFailedContainer(err.TerminatedContainers, true); state != nil {
+		return int(state.ExitCode)
+	}
+	return 1
+}
+
+func lastFailedContainer(states []ContainerTerminatedState, initContainer bool) *ContainerTerminatedState {
+	var last *ContainerTerminatedState
+	for i := range states {
+		state := &states[i]
+		if state.InitContainer != initContainer || state.ExitCode == 0 {
+			continue
+		}
+		if last == nil || !state.FinishedAt.Before(last.FinishedAt) {
+			last = state
+		}
+	}
+	return last
+}
+
+// JobDeadlineExceededError represents an error that the Job has exceeded the deadline.
+type JobDeadlineExceededError struct {
+	JobNamespace string
+	JobName      string
+}
+
+func (err JobDeadlineExceededError) Error() string {
+	return fmt.Sprintf("job %s/%s exceeded the deadline", err.JobNamespace, err.JobName)
+}
+
+// JobDeletedError represents an error that the Job was deleted before finished,
+// such as by an operator or the history limit of the CronJob.
+type JobDeletedError struct {
+	JobNamespace string
+	JobName      string
+
+	// Status is the last known status of the Job.
+	Status batchv1.JobStatus
+}
+
+func (err JobDeletedError) Error() string {
+	return fmt.Sprintf("job %s/%s was deleted before finished", err.JobNamespace, err.JobName)
+}
+
+// CanceledError represents an error that the context is canceled, such as by a signal.
+// The Job may be still running in the cluster.
+type CanceledError struct {
+	Cause error
+}
+
+func (err CanceledError) Error() string {
+	return fmt.Sprintf("canceled: %s", err.Cause)
+}
+
+func (err CanceledError) Unwrap() error {
+	return err.Cause
+}
+
+// InfrastructureError represents an error of the Kubernetes API server or the connection to it,
+// such as a timeout or an unavailable server.
+// It is not caused by the Job itself.
+// If JobName is empty, no Job has been created and the caller may retry.
+// Otherwise, the Job may be still running in the cluster,
+// and the caller should attach to the Job by AttachJob() instead of creating a new one.
+type InfrastructureError struct {
+	Err error
+
+	// JobNamespace and JobName are set if the Job has been created before the error.
+	JobNamespace string
+	JobName      string
+}
+
+func (err InfrastructureError) Error() string {
+	if err.JobName != "" {
+		return fmt.Sprintf("infrastructure error while waiting for job %s/%s: %s", err.JobNamespace, err.JobName, err.Err)
+	}
+	return fmt.Sprintf("infrastructure error: %s", err.Err)
+}
+
+func (err InfrastructureError) Unwrap() error {
+	return err.Err
+}
+
+// DryRunMode represents a mode of dry-run.
+type DryRunMode string
+
+const (
+	// DryRunNone runs the Job.
+	DryRunNone DryRunMode = ""
+	// DryRunClient prints the Job and Secret without sending them to the API server.
+	DryRunClient DryRunMode = "client"
+	// DryRunServer submits the Job and Secret to the API server in dry-run mode, and prints them.
+	// This exercises the validation, admission webhooks and quotas.
+	DryRunServer DryRunMode = "server"
+)
+
+// CancelPolicy represents a policy to clean up the Job when the context is canceled.
+type CancelPolicy string
+
+const (
+	// CancelPolicyKeep keeps the Job running in the cluster.
+	CancelPolicyKeep CancelPolicy = "keep"
+	// CancelPolicyDelete deletes the Job in foreground, and waits for the Pods to be terminated.
+	CancelPolicyDelete CancelPolicy = "delete"
+	// CancelPolicySuspend suspends the Job. The Job controller terminates the running Pods.
+	CancelPolicySuspend CancelPolicy = "suspend"
+)
+
+// CIProvider represents the CI system to write the output for.
+type CIProvider string
+
+const (
+	// CIProviderNone writes no CI-specific output.
+	CIProviderNone CIProvider = ""
+	// CIProviderGitHubActions writes the workflow commands of GitHub Actions.
+	CIProviderGitHubActions CIProvider = "github"
+	// CIProviderGitLab writes the collapsible sections of GitLab CI.
+	CIProviderGitLab CIProvider = "gitlab"
+	// CIProviderBuildkite writes the collapsible sections and annotations of Buildkite.
+	CIProviderBuildkite CIProvider = "buildkite"
+)
+
+// DetectCIProvider returns the CI system from the environment variables.
+func DetectCIProvider() CIProvider {
+	if name := ci.Detect(); name != ci.None {
+		return CIProvider(name)
+	}
+	return CIProviderNone
+}
+
+// ContainerTerminatedState represents the terminated state of a container.
+type ContainerTerminatedState = pods.ContainerTerminatedState
+
 // ContainerLogRecord represents a record of container logs.
 type ContainerLogRecord = logs.Record
 
+// ContainerType represents the type of a container.
+type ContainerType = pods.ContainerType
+
+const (
+	// ContainerTypeInit is an init container.
+	ContainerTypeInit = pods.ContainerTypeInit
+	// ContainerTypeSidecar is an init container with restartPolicy=Always.
+	ContainerTypeSidecar = pods.ContainerTypeSidecar
+	// ContainerTypeMain is a regular container.
+	ContainerTypeMain = pods.ContainerTypeMain
+)
+
 // ContainerLogger is an interface to handle the container logs.
 type ContainerLogger interface {
 	// Handle processes a line of container logs.
 	Handle(record ContainerLogRecord)
 }
 
-type defaultContainerLogger struct{}
+// ContainerLogStreamEnd represents the end of a container log stream.
+type ContainerLogStreamEnd = logs.StreamEnd
+
+// ErrContainerLogStreamLost is wrapped by ContainerLogStreamEnd.Err
+// when the retry of tailing the container log is exhausted.
+// The container log may be incomplete.
+var ErrContainerLogStreamLost = logs.ErrStreamLost
 
-func (defaultContainerLogger) Handle(record ContainerLogRecord) {
-	fmt.Println(record.Message)
+// ContainerLogStreamEndHandler is an optional interface of ContainerLogger.
+// If a ContainerLogger implements it, HandleStreamEnd is called
+// when the stream of a container is reached to EOF or stopped.
+type ContainerLogStreamEndHandler interface {
+	// HandleStreamEnd processes the end of a container log stream.
+	HandleStreamEnd(end ContainerLogStreamEnd)
 }
@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJobFailedError_ExitCode(t *testing.T) {
+	t0 := time.Date(2024, 3, 23, 0, 0, 0, 0, time.UTC)
+	for name, tc := range map[string]struct {
+		terminated []ContainerTerminatedState
+		want       int
+	}{
+		"no container": {
+			want: 1,
+		},
+		"main container is preferred to init container": {
+			terminated: []ContainerTerminatedState{
+				{PodName: "example-pod", ContainerName: "app", ExitCode: 2, FinishedAt: t0},
+				{PodName: "example-pod", ContainerName: "init", InitContainer: true, ExitCode: 3, FinishedAt: t0.Add(time.Minute)},
+			},
+			want: 2,
+		},
+		"init container failed before main container": {
+			terminated: []ContainerTerminatedState{
+				{PodName: "example-pod", ContainerName: "init", InitContainer: true, ExitCode: 3, FinishedAt: t0},
+			},
+			want: 3,
+		},
+		"latest container wins": {
+			terminated: []ContainerTerminatedState{
+				{PodName: "example-pod-2", ContainerName: "app", ExitCode: 137, FinishedAt: t0.Add(time.Minute)},
+				{PodName: "example-pod-1", ContainerName: "app", ExitCode: 2, FinishedAt: t0},
+			},
+			want: 137,
+		},
+		"all containers exited with zero": {
+			terminated: []ContainerTerminatedState{
+				{PodName: "example-pod", ContainerName: "init", InitContainer: true, ExitCode: 0, FinishedAt: t0},
+				{PodName: "example-pod", ContainerName: "app", ExitCode: 0, FinishedAt: t0.Add(time.Minute)},
+			},
+			want: 1,
+		},
+		"sidecar container is terminated after main container": {
+			terminated: []ContainerTerminatedState{
+				{PodName: "example-pod", ContainerName: "app", ExitCode: 2, FinishedAt: t0},
+				{PodName: "example-pod", ContainerName: "sidecar", InitContainer: true, ExitCode: 143, FinishedAt: t0.Add(time.Second)},
+			},
+			want: 2,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := JobFailedError{TerminatedContainers: tc.terminated}
+			if got := err.ExitCode(); got != tc.want {
+				t.Errorf("ExitCode wants %d but got %d", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
+)
+
+// watchErrorTimeout is the maximum duration of consecutive watch errors of the informers.
+// The reflector retries on errors forever, so the command would hang
+// if the API server is unreachable during the wait.
+const watchErrorTimeout = 5 * time.Minute
+
+// watchErrorResetInterval is the interval to consider the watch errors as consecutive.
+// The reflector retries with backoff capped at 30 seconds.
+const watchErrorResetInterval = time.Minute
+
+// informerSyncTimeout is the maximum duration to wait for the initial list of the informers.
+const informerSyncTimeout = time.Minute
+
+// watchErrorMonitor detects the consecutive errors of the API server or the connection
+// reported by the informers.
+type watchErrorMonitor struct {
+	// errCh receives the last error when the errors continue longer than maxDuration.
+	errCh       chan error
+	maxDuration time.Duration
+	now         func() time.Time
+
+	mu             sync.Mutex
+	firstErrorTime time.Time
+	lastErrorTime  time.Time
+	lastErr        error
+}
+
+func newWatchErrorMonitor(maxDuration time.Duration) *watchErrorMonitor {
+	return &watchErrorMonitor{
+		errCh:       make(chan error, 1),
+		maxDuration: maxDuration,
+		now:         time.Now,
+	}
+}
+
+// handle implements cache.WatchErrorHandler.
+func (m *watchErrorMonitor) handle(r *cache.Reflector, err error) {
+	cache.DefaultWatchErrorHandler(context.Background(), r, err)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		kerrors.IsResourceExpired(err) || kerrors.IsGone(err) {
+		// The watch is closed normally, and the reflector resumes it.
+		return
+	}
+	if !isInfrastructureError(err) {
+		// The reflector retries such as Forbidden, but it is not caused by the infrastructure.
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.lastErr = err
+		return
+	}
+	m.record(err)
+}
+
+func (m *watchErrorMonitor) record(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if m.lastErrorTime.IsZero() || now.Sub(m.lastErrorTime) > watchErrorResetInterval {
+		m.firstErrorTime = now
+	}
+	m.lastErrorTime = now
+	m.lastErr = err
+	if now.Sub(m.firstErrorTime) > m.maxDuration {
+		// Do not block if the error has already been sent.
+		select {
+		case m.errCh <- fmt.Errorf("watch failed for %s: %w", now.Sub(m.firstErrorTime).Round(time.Second), err):
+		default:
+		}
+	}
+}
+
+// lastError returns the last error, or nil if no error.
+func (m *watchErrorMonitor) lastError() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastErr
+}
+
+// cacheSyncer is an informer to wait for the initial list.
+type cacheSyncer interface {
+	WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool
+}
+
+// waitForCacheSync waits for the initial list of the informer until the timeout.
+// If the informer is not synced, it returns InfrastructureError.
+// If the context is canceled, it returns CanceledError.
+func waitForCacheSync(ctx context.Context, informer cacheSyncer, timeout time.Duration, monitor *watchErrorMonitor) error {
+	syncCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for typ, synced := range informer.WaitForCacheSync(syncCtx.Done()) {
+		if synced {
+			continue
+		}
+		if ctx.Err() != nil {
+			return CanceledError{Cause: context.Cause(ctx)}
+		}
+		if err := monitor.lastError(); err != nil {
+			return InfrastructureError{Err: fmt.Errorf("informer of %s did not sync within %s: %w", typ, timeout, err)}
+		}
+		return InfrastructureError{Err: fmt.Errorf("informer of %s did not sync within %s", typ, timeout)}
+	}
+	return nil
+}
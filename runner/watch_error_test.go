@@ -0,0 +1,122 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestWatchErrorMonitor(t *testing.T) {
+	t0 := time.Date(2024, 3, 23, 0, 0, 0, 0, time.UTC)
+	connectionRefused := errors.New("connection refused")
+
+	t.Run("errors continue longer than the max duration", func(t *testing.T) {
+		m := newWatchErrorMonitor(time.Minute)
+		now := t0
+		m.now = func() time.Time { return now }
+		for range 4 {
+			m.record(connectionRefused)
+			now = now.Add(30 * time.Second)
+		}
+		select {
+		case err := <-m.errCh:
+			if !errors.Is(err, connectionRefused) {
+				t.Errorf("error wants %s but got %s", connectionRefused, err)
+			}
+		default:
+			t.Errorf("errCh must receive an error")
+		}
+	})
+
+	t.Run("errors are not consecutive", func(t *testing.T) {
+		m := newWatchErrorMonitor(time.Minute)
+		now := t0
+		m.now = func() time.Time { return now }
+		for range 4 {
+			m.record(connectionRefused)
+			now = now.Add(2 * watchErrorResetInterval)
+		}
+		select {
+		case err := <-m.errCh:
+			t.Errorf("errCh wants no error but got %s", err)
+		default:
+		}
+	})
+
+	t.Run("watch is closed normally", func(t *testing.T) {
+		m := newWatchErrorMonitor(time.Minute)
+		r := cache.NewReflector(&cache.ListWatch{}, &corev1.Pod{}, cache.NewStore(cache.MetaNamespaceKeyFunc), 0)
+		for _, err := range []error{
+			io.EOF,
+			kerrors.NewResourceExpired("too old resource version"),
+		} {
+			m.handle(r, err)
+		}
+		if err := m.lastError(); err != nil {
+			t.Errorf("lastError wants nil but got %s", err)
+		}
+	})
+
+	t.Run("watch is forbidden", func(t *testing.T) {
+		m := newWatchErrorMonitor(0)
+		r := cache.NewReflector(&cache.ListWatch{}, &corev1.Pod{}, cache.NewStore(cache.MetaNamespaceKeyFunc), 0)
+		forbidden := kerrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("forbidden"))
+		m.handle(r, forbidden)
+		m.handle(r, forbidden)
+		if err := m.lastError(); !kerrors.IsForbidden(err) {
+			t.Errorf("lastError wants Forbidden but got %v", err)
+		}
+		select {
+		case err := <-m.errCh:
+			t.Errorf("errCh wants no error but got %s", err)
+		default:
+		}
+	})
+}
+
+type fakeCacheSyncer struct {
+	synced bool
+}
+
+func (f fakeCacheSyncer) WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool {
+	if !f.synced {
+		<-stopCh
+	}
+	return map[reflect.Type]bool{reflect.TypeFor[struct{}](): f.synced}
+}
+
+func TestWaitForCacheSync(t *testing.T) {
+	t.Run("synced", func(t *testing.T) {
+		m := newWatchErrorMonitor(time.Minute)
+		if err := waitForCacheSync(t.Context(), fakeCacheSyncer{synced: true}, time.Second, m); err != nil {
+			t.Errorf("waitForCacheSync wants nil but got %s", err)
+		}
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		m := newWatchErrorMonitor(time.Minute)
+		m.record(errors.New("connection refused"))
+		err := waitForCacheSync(t.Context(), fakeCacheSyncer{}, time.Millisecond, m)
+		if !errors.As(err, &InfrastructureError{}) {
+			t.Errorf("waitForCacheSync wants InfrastructureError but got %#v", err)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		m := newWatchErrorMonitor(time.Minute)
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		err := waitForCacheSync(ctx, fakeCacheSyncer{}, time.Minute, m)
+		if !errors.As(err, &CanceledError{}) {
+			t.Errorf("waitForCacheSync wants CanceledError but got %#v", err)
+		}
+	})
+}